@@ -6,6 +6,7 @@ import (
 	"github.com/ryanpetris/aur-builder/config"
 	"github.com/ryanpetris/aur-builder/git"
 	"github.com/ryanpetris/aur-builder/pkg"
+	"time"
 )
 
 func ClonePackage(pkgbase string, version string) error {
@@ -17,7 +18,9 @@ func ClonePackage(pkgbase string, version string) error {
 
 	aurUrl := config.GetArchPackageGitUrl(pkgbase)
 
-	if err := git.CloneUpstream(pkgbase, aurUrl, version); err != nil {
+	upstreamCommit, err := git.CloneUpstream(pkgbase, aurUrl, version)
+
+	if err != nil {
 		return err
 	}
 
@@ -28,6 +31,7 @@ func ClonePackage(pkgbase string, version string) error {
 	}
 
 	pconfig.Source = "arch"
+	pconfig.UpstreamCommit = upstreamCommit
 
 	if err := pconfig.CleanPkgrelBumpVersions(version); err != nil {
 		return err
@@ -39,3 +43,7 @@ func ClonePackage(pkgbase string, version string) error {
 
 	return nil
 }
+
+func GetReleaseTime(pkgbase string, version string) (time.Time, error) {
+	return git.GetTagTime(config.GetArchPackageGitUrl(pkgbase), version)
+}
@@ -68,3 +68,21 @@ func GetPackageVersion(pkgname string) (string, error) {
 
 	return "", nil
 }
+
+func GetRepositoryPackageVersion(db string, pkgname string) (string, error) {
+	slog.Debug(fmt.Sprintf("Looking up version for package %s in repository %s", pkgname, db))
+
+	query := "SELECT version FROM packages WHERE db = :db AND package = :package"
+	params := []any{
+		sql.Named("db", db),
+		sql.Named("package", pkgname),
+	}
+
+	var version string
+
+	if err := pacdb.QueryRow(query, params, &version); err != nil {
+		return "", err
+	}
+
+	return version, nil
+}
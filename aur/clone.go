@@ -17,7 +17,9 @@ func ClonePackage(pkgbase string, version string) error {
 
 	aurUrl := config.GetAurPackageGitUrl(pkgbase)
 
-	if err := git.CloneUpstream(pkgbase, aurUrl, ""); err != nil {
+	upstreamCommit, err := git.CloneUpstream(pkgbase, aurUrl, "")
+
+	if err != nil {
 		return err
 	}
 
@@ -28,6 +30,7 @@ func ClonePackage(pkgbase string, version string) error {
 	}
 
 	pconfig.Source = "aur"
+	pconfig.UpstreamCommit = upstreamCommit
 
 	if err := pconfig.CleanPkgrelBumpVersions(version); err != nil {
 		return err
@@ -1,8 +1,8 @@
 package aur
 
 import (
+	"github.com/ryanpetris/aur-builder/pacman"
 	"strconv"
-	"strings"
 )
 
 type Package struct {
@@ -48,40 +48,35 @@ type PackageSearchResults struct {
 }
 
 func (pkg *Package) GetEpoch() int {
-	parts := strings.SplitN(pkg.Version, ":", 2)
+	version, err := pacman.ParseVersion(pkg.Version)
 
-	if len(parts) == 2 {
-		result, _ := strconv.Atoi(parts[0])
-
-		return result
+	if err != nil {
+		return 0
 	}
 
-	return 0
+	result, _ := strconv.Atoi(version.Epoch)
+
+	return result
 }
 
 func (pkg *Package) GetPkgrel() int {
-	version := pkg.Version
-	parts := strings.SplitN(version, ":", 2)
+	version, err := pacman.ParseVersion(pkg.Version)
 
-	if len(parts) == 2 {
-		version = parts[1]
+	if err != nil {
+		return 0
 	}
 
-	parts = strings.SplitN(version, "-", 2)
-	result, _ := strconv.Atoi(parts[1])
+	result, _, _ := version.GetPkgrelParts()
 
 	return result
 }
 
 func (pkg *Package) GetPkgver() string {
-	version := pkg.Version
-	parts := strings.SplitN(version, ":", 2)
+	version, err := pacman.ParseVersion(pkg.Version)
 
-	if len(parts) == 2 {
-		version = parts[1]
+	if err != nil {
+		return ""
 	}
 
-	parts = strings.SplitN(version, "-", 2)
-
-	return parts[0]
+	return version.Pkgver
 }
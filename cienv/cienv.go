@@ -3,12 +3,22 @@ package cienv
 import (
 	"fmt"
 	"github.com/go-git/go-git/v5"
+	"github.com/ryanpetris/aur-builder/forge"
+	"slices"
+)
+
+const (
+	PrStateOpen   = forge.StateOpen
+	PrStateClosed = forge.StateClosed
+	PrStateMerged = forge.StateMerged
 )
 
 type CiEnv interface {
 	IsCI() bool
-	CreatePR() error
-	WriteBuildPackages(pkgbase []string) error
+	CreatePR(branchName string, title string, body string, labels []string) (string, error)
+	GetPRState(branchName string) (string, error)
+	ClosePR(branchName string, comment string) error
+	WriteBuildPackages(packages map[string][]string) error
 	SetGitCommitOptions(options *git.CommitOptions) error
 	SetGitPushOptions(options *git.PushOptions) error
 }
@@ -20,14 +30,20 @@ func (env DefaultCiEnv) IsCI() bool {
 	return false
 }
 
-func (env DefaultCiEnv) CreatePR() error {
+func (env DefaultCiEnv) CreatePR(branchName string, title string, body string, labels []string) (string, error) {
+	return "", nil
+}
+
+func (env DefaultCiEnv) GetPRState(branchName string) (string, error) {
+	return "", nil
+}
+
+func (env DefaultCiEnv) ClosePR(branchName string, comment string) error {
 	return nil
 }
 
-func (env DefaultCiEnv) WriteBuildPackages(pkgbase []string) error {
-	for _, pkgb := range pkgbase {
-		fmt.Printf("%s needs update\n", pkgb)
-	}
+func (env DefaultCiEnv) WriteBuildPackages(packages map[string][]string) error {
+	printBuildPackages(packages)
 
 	return nil
 }
@@ -39,3 +55,27 @@ func (env DefaultCiEnv) SetGitCommitOptions(options *git.CommitOptions) error {
 func (env DefaultCiEnv) SetGitPushOptions(options *git.PushOptions) error {
 	return nil
 }
+
+func getBuildRepositories(packages map[string][]string) []string {
+	var result []string
+
+	for repository := range packages {
+		result = append(result, repository)
+	}
+
+	slices.Sort(result)
+
+	return result
+}
+
+func printBuildPackages(packages map[string][]string) {
+	for _, repository := range getBuildRepositories(packages) {
+		for _, pkgb := range packages[repository] {
+			if repository == "" {
+				fmt.Printf("%s needs update\n", pkgb)
+			} else {
+				fmt.Printf("%s needs update in %s\n", pkgb, repository)
+			}
+		}
+	}
+}
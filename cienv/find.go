@@ -1,6 +1,27 @@
 package cienv
 
+import (
+	"fmt"
+	"github.com/ryanpetris/aur-builder/config"
+)
+
 func FindCiEnv() CiEnv {
+	switch ciEnv := config.GetCiEnv(); ciEnv {
+	case "auto":
+	case "forgejo":
+		return ForgejoCiEnv{}
+	case "github":
+		return GithubCiEnv{}
+	case "gitlab":
+		return GitlabCiEnv{}
+	case "local":
+		return LocalCiEnv{}
+	case "none":
+		return DefaultCiEnv{}
+	default:
+		panic(fmt.Sprintf("Invalid CI environment: %s", ciEnv))
+	}
+
 	if fjenv := (ForgejoCiEnv{}); fjenv.IsCI() {
 		return fjenv
 	}
@@ -9,5 +30,9 @@ func FindCiEnv() CiEnv {
 		return ghenv
 	}
 
+	if glenv := (GitlabCiEnv{}); glenv.IsCI() {
+		return glenv
+	}
+
 	return DefaultCiEnv{}
 }
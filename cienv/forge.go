@@ -0,0 +1,87 @@
+package cienv
+
+import (
+	"fmt"
+	"github.com/ryanpetris/aur-builder/config"
+	"github.com/ryanpetris/aur-builder/forge"
+	"log/slog"
+)
+
+func createForgePR(client forge.Client, repository string, branchName string, title string, body string, labels []string) (string, error) {
+	headRepository, err := getHeadRepository(repository)
+
+	if err != nil {
+		return "", err
+	}
+
+	pr, err := client.FindPullRequest(headRepository, branchName)
+
+	if err != nil {
+		return "", err
+	}
+
+	if pr != nil && pr.State == forge.StateOpen {
+		slog.Info(fmt.Sprintf("Reusing open pull request %s for branch %s", pr.Url, branchName))
+		return pr.Url, nil
+	}
+
+	pr, err = client.CreatePullRequest(&forge.PullRequestOptions{
+		HeadRepository: headRepository,
+		HeadBranch:     branchName,
+		BaseBranch:     config.GetBaseBranch(),
+		Title:          title,
+		Body:           body,
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	if err := client.AddLabels(pr.Number, labels); err != nil {
+		return "", err
+	}
+
+	if err := client.AddReviewers(pr.Number, config.GetPrReviewers()); err != nil {
+		return "", err
+	}
+
+	return pr.Url, nil
+}
+
+func getForgePRState(client forge.Client, repository string, branchName string) (string, error) {
+	headRepository, err := getHeadRepository(repository)
+
+	if err != nil {
+		return "", err
+	}
+
+	pr, err := client.FindPullRequest(headRepository, branchName)
+
+	if err != nil || pr == nil {
+		return "", err
+	}
+
+	return pr.State, nil
+}
+
+func closeForgePR(client forge.Client, repository string, branchName string, comment string) error {
+	headRepository, err := getHeadRepository(repository)
+
+	if err != nil {
+		return err
+	}
+
+	pr, err := client.FindPullRequest(headRepository, branchName)
+
+	if err != nil || pr == nil || pr.State != forge.StateOpen {
+		return err
+	}
+
+	if comment != "" {
+		if err := client.AddComment(pr.Number, comment); err != nil {
+			return err
+		}
+	}
+
+	return client.ClosePullRequest(pr.Number)
+}
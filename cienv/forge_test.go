@@ -0,0 +1,72 @@
+package cienv
+
+import (
+	"github.com/ryanpetris/aur-builder/forge"
+	"testing"
+)
+
+type testForgeClient struct {
+	existing *forge.PullRequest
+	created  []*forge.PullRequestOptions
+	labeled  []int
+}
+
+func (client *testForgeClient) CreatePullRequest(options *forge.PullRequestOptions) (*forge.PullRequest, error) {
+	client.created = append(client.created, options)
+
+	return &forge.PullRequest{Number: 10, State: forge.StateOpen, Url: "https://example.com/pulls/10"}, nil
+}
+
+func (client *testForgeClient) FindPullRequest(headRepository string, headBranch string) (*forge.PullRequest, error) {
+	return client.existing, nil
+}
+
+func (client *testForgeClient) ClosePullRequest(number int) error {
+	return nil
+}
+
+func (client *testForgeClient) AddComment(number int, body string) error {
+	return nil
+}
+
+func (client *testForgeClient) AddLabels(number int, labels []string) error {
+	client.labeled = append(client.labeled, number)
+	return nil
+}
+
+func (client *testForgeClient) AddReviewers(number int, reviewers []string) error {
+	return nil
+}
+
+func TestCreateForgePR(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing *forge.PullRequest
+		url      string
+		created  int
+	}{
+		{"no existing", nil, "https://example.com/pulls/10", 1},
+		{"reuse open", &forge.PullRequest{Number: 3, State: forge.StateOpen, Url: "https://example.com/pulls/3"}, "https://example.com/pulls/3", 0},
+		{"closed", &forge.PullRequest{Number: 3, State: forge.StateClosed, Url: "https://example.com/pulls/3"}, "https://example.com/pulls/10", 1},
+		{"merged", &forge.PullRequest{Number: 3, State: forge.StateMerged, Url: "https://example.com/pulls/3"}, "https://example.com/pulls/10", 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &testForgeClient{existing: test.existing}
+			url, err := createForgePR(client, "owner/repo", "update/foo", "title", "body", []string{"label"})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if url != test.url {
+				t.Errorf("expected url %s, got %s", test.url, url)
+			}
+
+			if len(client.created) != test.created || len(client.labeled) != test.created {
+				t.Errorf("expected %d created pull requests, got %d (labeled %d)", test.created, len(client.created), len(client.labeled))
+			}
+		})
+	}
+}
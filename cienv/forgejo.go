@@ -1,20 +1,22 @@
 package cienv
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/go-git/go-git/v5"
 	gitobject "github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/ryanpetris/aur-builder/forge"
 	"os"
-	"os/exec"
-	"strings"
 )
 
 type ForgejoCiEnv struct {
 }
 
+// Clients are kept for the whole run so pull request listings are only
+// fetched once.
+var giteaClients = map[string]*forge.GiteaClient{}
+
 func (env ForgejoCiEnv) IsCI() bool {
 	if value := os.Getenv("GITEA_ACTIONS"); value == "true" {
 		return true
@@ -23,93 +25,38 @@ func (env ForgejoCiEnv) IsCI() bool {
 	return false
 }
 
-func (env ForgejoCiEnv) CreatePR() error {
-	if !env.IsCI() {
-		return errors.New("Not in CI environment")
-	}
-
-	branchBytes, err := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD").Output()
-
-	if err != nil {
-		return err
-	}
-
-	branchName := strings.SplitN(string(branchBytes), "\n", 2)[0]
-	messageBytes, err := exec.Command("git", "log", "-n", "1", "--pretty=%B").Output()
+func (env ForgejoCiEnv) CreatePR(branchName string, title string, body string, labels []string) (string, error) {
+	client, repository, err := env.getForgeClient()
 
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	title := strings.SplitN(string(messageBytes), "\n", 2)[0]
-
-	data := map[string]any{
-		"head":  branchName,
-		"base":  "master",
-		"title": title,
-	}
+	return createForgePR(client, repository, branchName, title, body, labels)
+}
 
-	dataBytes, err := json.Marshal(data)
+func (env ForgejoCiEnv) GetPRState(branchName string) (string, error) {
+	client, repository, err := env.getForgeClient()
 
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	authUsername := "me"
-	authPassword := os.Getenv("GITHUB_TOKEN")
-
-	if val := os.Getenv("REPOSITORY_WRITE_USERNAME"); val != "" {
-		authUsername = val
-	}
-
-	if val := os.Getenv("REPOSITORY_WRITE_TOKEN"); val != "" {
-		authPassword = val
-	}
-
-	cmd := exec.Command(
-		"curl", "-X", "POST",
-		fmt.Sprintf("%s/repos/%s/pulls", os.Getenv("GITHUB_API_URL"), os.Getenv("GITHUB_REPOSITORY")),
-		"--insecure",
-		"--silent",
-		"--fail",
-		"--user", fmt.Sprintf("%s:%s", authUsername, authPassword),
-		"--header", "Content-Type: application/json",
-		"--data-raw", string(dataBytes),
-	)
-
-	if err := cmd.Run(); err != nil {
-		return err
-	}
-
-	return nil
+	return getForgePRState(client, repository, branchName)
 }
 
-func (env ForgejoCiEnv) WriteBuildPackages(pkgbase []string) error {
-	dataJson, err := json.Marshal(pkgbase)
+func (env ForgejoCiEnv) ClosePR(branchName string, comment string) error {
+	client, repository, err := env.getForgeClient()
 
 	if err != nil {
 		return err
 	}
 
-	data := fmt.Sprintf("packages=%s", dataJson)
-
-	if ghOutputFile := os.Getenv("GITHUB_OUTPUT"); ghOutputFile != "" {
-		ghOutput, err := os.OpenFile(ghOutputFile, os.O_APPEND|os.O_WRONLY, 0666)
-
-		if err != nil {
-			return err
-		}
-
-		defer ghOutput.Close()
-
-		if _, err = ghOutput.WriteString(data); err != nil {
-			return err
-		}
-	} else {
-		fmt.Println(data)
-	}
+	return closeForgePR(client, repository, branchName, comment)
+}
 
-	return nil
+func (env ForgejoCiEnv) WriteBuildPackages(packages map[string][]string) error {
+	return writeActionsBuildPackages(packages)
 }
 
 func (env ForgejoCiEnv) SetGitCommitOptions(options *git.CommitOptions) error {
@@ -130,3 +77,37 @@ func (env ForgejoCiEnv) SetGitPushOptions(options *git.PushOptions) error {
 
 	return nil
 }
+
+func (env ForgejoCiEnv) getForgeClient() (forge.Client, string, error) {
+	if !env.IsCI() {
+		return nil, "", errors.New("Not in CI environment")
+	}
+
+	repository, err := getTargetRepository(os.Getenv("GITHUB_REPOSITORY"))
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	authUsername := "me"
+	authPassword := os.Getenv("GITHUB_TOKEN")
+
+	if val := os.Getenv("REPOSITORY_WRITE_USERNAME"); val != "" {
+		authUsername = val
+	}
+
+	if val := os.Getenv("REPOSITORY_WRITE_TOKEN"); val != "" {
+		authPassword = val
+	}
+
+	apiUrl := os.Getenv("GITHUB_API_URL")
+	key := fmt.Sprintf("%s\x00%s\x00%s\x00%s", apiUrl, repository, authUsername, authPassword)
+	client, ok := giteaClients[key]
+
+	if !ok {
+		client = forge.NewGiteaClient(apiUrl, repository, authUsername, authPassword)
+		giteaClients[key] = client
+	}
+
+	return client, repository, nil
+}
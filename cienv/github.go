@@ -7,8 +7,8 @@ import (
 	"github.com/go-git/go-git/v5"
 	gitobject "github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/ryanpetris/aur-builder/forge"
 	"os"
-	"os/exec"
 )
 
 type GithubCiEnv struct {
@@ -22,46 +22,38 @@ func (env GithubCiEnv) IsCI() bool {
 	return false
 }
 
-func (env GithubCiEnv) CreatePR() error {
-	if !env.IsCI() {
-		return errors.New("Not in CI environment")
-	}
-
-	cmd := exec.Command("gh", "pr", "create", "--fill", "--base", "master")
+func (env GithubCiEnv) CreatePR(branchName string, title string, body string, labels []string) (string, error) {
+	client, repository, err := env.getForgeClient()
 
-	if err := cmd.Run(); err != nil {
-		return err
+	if err != nil {
+		return "", err
 	}
 
-	return nil
+	return createForgePR(client, repository, branchName, title, body, labels)
 }
 
-func (env GithubCiEnv) WriteBuildPackages(pkgbase []string) error {
-	dataJson, err := json.Marshal(pkgbase)
+func (env GithubCiEnv) GetPRState(branchName string) (string, error) {
+	client, repository, err := env.getForgeClient()
 
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	data := fmt.Sprintf("packages=%s", dataJson)
-
-	if ghOutputFile := os.Getenv("GITHUB_OUTPUT"); ghOutputFile != "" {
-		ghOutput, err := os.OpenFile(ghOutputFile, os.O_APPEND|os.O_WRONLY, 0666)
+	return getForgePRState(client, repository, branchName)
+}
 
-		if err != nil {
-			return err
-		}
+func (env GithubCiEnv) ClosePR(branchName string, comment string) error {
+	client, repository, err := env.getForgeClient()
 
-		defer ghOutput.Close()
-
-		if _, err = ghOutput.WriteString(data); err != nil {
-			return err
-		}
-	} else {
-		fmt.Println(data)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	return closeForgePR(client, repository, branchName, comment)
+}
+
+func (env GithubCiEnv) WriteBuildPackages(packages map[string][]string) error {
+	return writeActionsBuildPackages(packages)
 }
 
 func (env GithubCiEnv) SetGitCommitOptions(options *git.CommitOptions) error {
@@ -82,3 +74,86 @@ func (env GithubCiEnv) SetGitPushOptions(options *git.PushOptions) error {
 
 	return nil
 }
+
+func (env GithubCiEnv) getForgeClient() (forge.Client, string, error) {
+	if !env.IsCI() {
+		return nil, "", errors.New("Not in CI environment")
+	}
+
+	repository, err := getTargetRepository(os.Getenv("GITHUB_REPOSITORY"))
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	token := os.Getenv("GITHUB_TOKEN")
+
+	if val := os.Getenv("REPOSITORY_WRITE_TOKEN"); val != "" {
+		token = val
+	}
+
+	return forge.NewGithubClient(os.Getenv("GITHUB_API_URL"), repository, token), repository, nil
+}
+
+func writeActionsBuildPackages(packages map[string][]string) error {
+	var data []string
+	var builds []map[string]string
+
+	for _, repository := range getBuildRepositories(packages) {
+		dataJson, err := json.Marshal(packages[repository])
+
+		if err != nil {
+			return err
+		}
+
+		if repository == "" {
+			data = append(data, fmt.Sprintf("packages=%s", dataJson))
+			continue
+		}
+
+		data = append(data, fmt.Sprintf("packages_%s=%s", repository, dataJson))
+
+		for _, pkgb := range packages[repository] {
+			builds = append(builds, map[string]string{
+				"repository": repository,
+				"pkgbase":    pkgb,
+			})
+		}
+	}
+
+	if _, ok := packages[""]; !ok {
+		if builds == nil {
+			builds = []map[string]string{}
+		}
+
+		buildsJson, err := json.Marshal(builds)
+
+		if err != nil {
+			return err
+		}
+
+		data = append(data, fmt.Sprintf("builds=%s", buildsJson))
+	}
+
+	if ghOutputFile := os.Getenv("GITHUB_OUTPUT"); ghOutputFile != "" {
+		ghOutput, err := os.OpenFile(ghOutputFile, os.O_APPEND|os.O_WRONLY, 0666)
+
+		if err != nil {
+			return err
+		}
+
+		defer ghOutput.Close()
+
+		for _, line := range data {
+			if _, err = ghOutput.WriteString(fmt.Sprintf("%s\n", line)); err != nil {
+				return err
+			}
+		}
+	} else {
+		for _, line := range data {
+			fmt.Println(line)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,207 @@
+package cienv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	gitobject "github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/ryanpetris/aur-builder/forge"
+	"gopkg.in/yaml.v3"
+	"os"
+	"strings"
+)
+
+type GitlabCiEnv struct {
+}
+
+func (env GitlabCiEnv) IsCI() bool {
+	if value := os.Getenv("GITLAB_CI"); value == "true" {
+		return true
+	}
+
+	return false
+}
+
+func (env GitlabCiEnv) CreatePR(branchName string, title string, body string, labels []string) (string, error) {
+	client, repository, err := env.getForgeClient()
+
+	if err != nil {
+		return "", err
+	}
+
+	return createForgePR(client, repository, branchName, title, body, labels)
+}
+
+func (env GitlabCiEnv) GetPRState(branchName string) (string, error) {
+	client, repository, err := env.getForgeClient()
+
+	if err != nil {
+		return "", err
+	}
+
+	return getForgePRState(client, repository, branchName)
+}
+
+func (env GitlabCiEnv) ClosePR(branchName string, comment string) error {
+	client, repository, err := env.getForgeClient()
+
+	if err != nil {
+		return err
+	}
+
+	return closeForgePR(client, repository, branchName, comment)
+}
+
+func (env GitlabCiEnv) WriteBuildPackages(packages map[string][]string) error {
+	dotenv := strings.Builder{}
+
+	for _, repository := range getBuildRepositories(packages) {
+		dataJson, err := json.Marshal(packages[repository])
+
+		if err != nil {
+			return err
+		}
+
+		if repository == "" {
+			dotenv.WriteString(fmt.Sprintf("PACKAGES=%s\n", dataJson))
+		} else {
+			dotenv.WriteString(fmt.Sprintf("PACKAGES_%s=%s\n", getGitlabVariableName(repository), dataJson))
+		}
+	}
+
+	dotenvFile := os.Getenv("GITLAB_DOTENV_FILE")
+
+	if dotenvFile == "" {
+		dotenvFile = "build.env"
+	}
+
+	if err := os.WriteFile(dotenvFile, []byte(dotenv.String()), 0644); err != nil {
+		return err
+	}
+
+	if pipelineFile := os.Getenv("GITLAB_CHILD_PIPELINE_FILE"); pipelineFile != "" {
+		pipelineBytes, err := yaml.Marshal(getGitlabChildPipeline(packages))
+
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(pipelineFile, pipelineBytes, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (env GitlabCiEnv) SetGitCommitOptions(options *git.CommitOptions) error {
+	if name := os.Getenv("GITLAB_USER_NAME"); name != "" {
+		options.Author = &gitobject.Signature{
+			Name:  name,
+			Email: os.Getenv("GITLAB_USER_EMAIL"),
+		}
+	}
+
+	return nil
+}
+
+func (env GitlabCiEnv) SetGitPushOptions(options *git.PushOptions) error {
+	if token := env.getToken(); token != "" {
+		username := "oauth2"
+
+		if val := os.Getenv("REPOSITORY_WRITE_USERNAME"); val != "" {
+			username = val
+		}
+
+		options.Auth = &http.BasicAuth{Username: username, Password: token}
+	}
+
+	return nil
+}
+
+func (env GitlabCiEnv) getForgeClient() (forge.Client, string, error) {
+	if !env.IsCI() {
+		return nil, "", errors.New("Not in CI environment")
+	}
+
+	project := os.Getenv("CI_PROJECT_ID")
+
+	if project == "" {
+		project = os.Getenv("CI_PROJECT_PATH")
+	}
+
+	repository, err := getTargetRepository(project)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return forge.NewGitlabClient(os.Getenv("CI_API_V4_URL"), repository, env.getToken()), repository, nil
+}
+
+func (env GitlabCiEnv) getToken() string {
+	if val := os.Getenv("REPOSITORY_WRITE_TOKEN"); val != "" {
+		return val
+	}
+
+	return os.Getenv("GITLAB_TOKEN")
+}
+
+func getGitlabChildPipeline(packages map[string][]string) map[string]any {
+	pipeline := map[string]any{}
+
+	if include := os.Getenv("GITLAB_CHILD_PIPELINE_INCLUDE"); include != "" {
+		pipeline["include"] = []map[string]string{{"local": include}}
+	}
+
+	extends := os.Getenv("GITLAB_CHILD_PIPELINE_EXTENDS")
+
+	if extends == "" {
+		extends = ".build-package"
+	}
+
+	jobs := 0
+
+	for repository, pkgbase := range packages {
+		for _, pkgb := range pkgbase {
+			jobs += 1
+
+			if repository == "" {
+				pipeline[fmt.Sprintf("build:%s", pkgb)] = map[string]any{
+					"extends": extends,
+					"variables": map[string]string{
+						"PKGBASE": pkgb,
+					},
+				}
+			} else {
+				pipeline[fmt.Sprintf("build:%s:%s", repository, pkgb)] = map[string]any{
+					"extends": extends,
+					"variables": map[string]string{
+						"PKGBASE":    pkgb,
+						"REPOSITORY": repository,
+					},
+				}
+			}
+		}
+	}
+
+	if jobs == 0 {
+		pipeline["no-packages"] = map[string]any{
+			"script": []string{"echo No packages need to be built"},
+		}
+	}
+
+	return pipeline
+}
+
+func getGitlabVariableName(name string) string {
+	return strings.ToUpper(strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+
+		return '_'
+	}, name))
+}
@@ -0,0 +1,278 @@
+package cienv
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"slices"
+	"testing"
+)
+
+type gitlabTestServer struct {
+	*httptest.Server
+	requests []string
+	existing []map[string]any
+	created  map[string]any
+	updated  map[string]any
+	token    string
+}
+
+func newGitlabTestServer(t *testing.T, project string) *gitlabTestServer {
+	server := &gitlabTestServer{}
+	prefix := fmt.Sprintf("/api/v4/projects/%s", project)
+
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.requests = append(server.requests, fmt.Sprintf("%s %s", r.Method, r.URL.EscapedPath()))
+		server.token = r.Header.Get("PRIVATE-TOKEN")
+
+		var result any
+
+		switch fmt.Sprintf("%s %s", r.Method, r.URL.EscapedPath()) {
+		case "GET " + prefix:
+			result = map[string]any{"id": 42}
+
+		case "GET " + prefix + "/merge_requests":
+			result = []map[string]any{}
+
+			if r.URL.Query().Get("page") == "1" && server.existing != nil {
+				result = server.existing
+			}
+
+		case "POST " + prefix + "/merge_requests":
+			if err := json.NewDecoder(r.Body).Decode(&server.created); err != nil {
+				t.Error(err)
+			}
+
+			result = map[string]any{"iid": 7, "state": "opened", "web_url": "https://gitlab.example.com/mr/7", "source_project_id": 42}
+
+		case "PUT " + prefix + "/merge_requests/7":
+			if err := json.NewDecoder(r.Body).Decode(&server.updated); err != nil {
+				t.Error(err)
+			}
+
+			result = map[string]any{}
+
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(result)
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+type gitlabTestJob struct {
+	Extends   string            `yaml:"extends"`
+	Variables map[string]string `yaml:"variables"`
+	Script    []string          `yaml:"script"`
+}
+
+func setGitlabTestEnv(t *testing.T, server *gitlabTestServer, projectId string, projectPath string) {
+	t.Setenv("GITLAB_CI", "true")
+	t.Setenv("CI_API_V4_URL", server.URL+"/api/v4")
+	t.Setenv("CI_PROJECT_ID", projectId)
+	t.Setenv("CI_PROJECT_PATH", projectPath)
+	t.Setenv("GITLAB_TOKEN", "token")
+	t.Setenv("REPOSITORY_WRITE_TOKEN", "")
+}
+
+func TestGitlabCreatePR(t *testing.T) {
+	server := newGitlabTestServer(t, "42")
+	setGitlabTestEnv(t, server, "42", "group/project")
+
+	url, err := GitlabCiEnv{}.CreatePR("packages/foo/1.0-1", "Update foo to 1.0-1", "body", []string{"update", "aur"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if url != "https://gitlab.example.com/mr/7" {
+		t.Errorf("unexpected merge request url %s", url)
+	}
+
+	if server.token != "token" {
+		t.Errorf("expected PRIVATE-TOKEN header, got %q", server.token)
+	}
+
+	expected := map[string]any{
+		"source_branch": "packages/foo/1.0-1",
+		"target_branch": "master",
+		"title":         "Update foo to 1.0-1",
+		"description":   "body",
+	}
+
+	if fmt.Sprint(server.created) != fmt.Sprint(expected) {
+		t.Errorf("expected merge request %v, got %v", expected, server.created)
+	}
+
+	if server.updated["add_labels"] != "update,aur" {
+		t.Errorf("expected labels to be added, got %v", server.updated)
+	}
+}
+
+func TestGitlabCreatePRReusesOpen(t *testing.T) {
+	server := newGitlabTestServer(t, "42")
+	setGitlabTestEnv(t, server, "42", "group/project")
+	server.existing = []map[string]any{
+		{"iid": 3, "state": "opened", "web_url": "https://gitlab.example.com/mr/3", "source_project_id": 42},
+	}
+
+	url, err := GitlabCiEnv{}.CreatePR("packages/foo/1.0-1", "Update foo to 1.0-1", "body", nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if url != "https://gitlab.example.com/mr/3" || server.created != nil {
+		t.Errorf("expected existing merge request to be reused, got %s (created %v)", url, server.created)
+	}
+}
+
+func TestGitlabProjectPathFallback(t *testing.T) {
+	server := newGitlabTestServer(t, "group%2Fproject")
+	setGitlabTestEnv(t, server, "", "group/project")
+
+	state, err := GitlabCiEnv{}.GetPRState("packages/foo/1.0-1")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if state != "" {
+		t.Errorf("expected no merge request, got state %s", state)
+	}
+
+	if !slices.Contains(server.requests, "GET /api/v4/projects/group%2Fproject") {
+		t.Errorf("expected project path to be used, got %v", server.requests)
+	}
+}
+
+func TestGitlabWriteBuildPackages(t *testing.T) {
+	dir := t.TempDir()
+	dotenvFile := path.Join(dir, "build.env")
+	pipelineFile := path.Join(dir, "pipeline.yml")
+
+	t.Setenv("GITLAB_DOTENV_FILE", dotenvFile)
+	t.Setenv("GITLAB_CHILD_PIPELINE_FILE", pipelineFile)
+	t.Setenv("GITLAB_CHILD_PIPELINE_INCLUDE", "ci/build.yml")
+	t.Setenv("GITLAB_CHILD_PIPELINE_EXTENDS", "")
+
+	tests := []struct {
+		name     string
+		packages map[string][]string
+		dotenv   string
+		jobs     map[string]map[string]string
+	}{
+		{
+			name:     "no repositories",
+			packages: map[string][]string{"": {"bar", "foo"}},
+			dotenv:   "PACKAGES=[\"bar\",\"foo\"]\n",
+			jobs: map[string]map[string]string{
+				"build:bar": {"PKGBASE": "bar"},
+				"build:foo": {"PKGBASE": "foo"},
+			},
+		},
+		{
+			name:     "repositories",
+			packages: map[string][]string{"extra-testing": {"foo"}, "core": {}},
+			dotenv:   "PACKAGES_CORE=[]\nPACKAGES_EXTRA_TESTING=[\"foo\"]\n",
+			jobs: map[string]map[string]string{
+				"build:extra-testing:foo": {"PKGBASE": "foo", "REPOSITORY": "extra-testing"},
+			},
+		},
+		{
+			name:     "nothing to build",
+			packages: map[string][]string{"": {}},
+			dotenv:   "PACKAGES=[]\n",
+			jobs:     map[string]map[string]string{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := (GitlabCiEnv{}).WriteBuildPackages(test.packages); err != nil {
+				t.Fatal(err)
+			}
+
+			dotenv, err := os.ReadFile(dotenvFile)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(dotenv) != test.dotenv {
+				t.Errorf("expected dotenv %q, got %q", test.dotenv, dotenv)
+			}
+
+			pipelineBytes, err := os.ReadFile(pipelineFile)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var pipeline map[string]yaml.Node
+
+			if err := yaml.Unmarshal(pipelineBytes, &pipeline); err != nil {
+				t.Fatal(err)
+			}
+
+			var include []map[string]string
+			includeNode := pipeline["include"]
+
+			if err := includeNode.Decode(&include); err != nil || len(include) != 1 || include[0]["local"] != "ci/build.yml" {
+				t.Errorf("expected local include, got %v (%v)", include, err)
+			}
+
+			delete(pipeline, "include")
+			jobs := map[string]*gitlabTestJob{}
+
+			for name, node := range pipeline {
+				job := &gitlabTestJob{}
+
+				if err := node.Decode(job); err != nil {
+					t.Fatal(err)
+				}
+
+				jobs[name] = job
+			}
+
+			if len(test.jobs) == 0 {
+				if job, ok := jobs["no-packages"]; !ok || len(job.Script) == 0 || len(jobs) != 1 {
+					t.Errorf("expected only a no-packages job, got %v", jobs)
+				}
+
+				return
+			}
+
+			if len(jobs) != len(test.jobs) {
+				t.Errorf("expected %d jobs, got %v", len(test.jobs), jobs)
+			}
+
+			for name, variables := range test.jobs {
+				job, ok := jobs[name]
+
+				if !ok {
+					t.Errorf("missing job %s", name)
+					continue
+				}
+
+				if job.Extends != ".build-package" {
+					t.Errorf("job %s extends %q", name, job.Extends)
+				}
+
+				if fmt.Sprint(job.Variables) != fmt.Sprint(variables) {
+					t.Errorf("job %s: expected variables %v, got %v", name, variables, job.Variables)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package cienv
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	gitobject "github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/ryanpetris/aur-builder/config"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type LocalCiEnv struct {
+}
+
+func (env LocalCiEnv) IsCI() bool {
+	return true
+}
+
+func (env LocalCiEnv) CreatePR(branchName string, title string, body string, labels []string) (string, error) {
+	outputPath := config.GetLocalOutputPath()
+
+	if err := os.MkdirAll(outputPath, 0777); err != nil {
+		return "", err
+	}
+
+	patch, err := formatPatch(branchName)
+
+	if err != nil {
+		return "", err
+	}
+
+	summaryPath, patchPath := getLocalOutputPaths(branchName)
+	summary := strings.Builder{}
+
+	summary.WriteString(fmt.Sprintf("# %s\n\n", title))
+	summary.WriteString(fmt.Sprintf("- **Branch:** `%s`\n", branchName))
+	summary.WriteString(fmt.Sprintf("- **Base:** `%s`\n", config.GetBaseBranch()))
+	summary.WriteString(fmt.Sprintf("- **Patch:** [%s](%s)\n", filepath.Base(patchPath), filepath.Base(patchPath)))
+
+	if len(labels) > 0 {
+		summary.WriteString(fmt.Sprintf("- **Labels:** %s\n", strings.Join(labels, ", ")))
+	}
+
+	if body != "" {
+		summary.WriteString(fmt.Sprintf("\n%s", body))
+	}
+
+	if err := os.WriteFile(patchPath, patch, 0666); err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(summaryPath, []byte(summary.String()), 0666); err != nil {
+		return "", err
+	}
+
+	slog.Info(fmt.Sprintf("Wrote summary for branch %s to %s", branchName, summaryPath))
+
+	return summaryPath, nil
+}
+
+func (env LocalCiEnv) GetPRState(branchName string) (string, error) {
+	summaryPath, _ := getLocalOutputPaths(branchName)
+
+	if _, err := os.Stat(summaryPath); errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	return PrStateOpen, nil
+}
+
+func (env LocalCiEnv) ClosePR(branchName string, comment string) error {
+	summaryPath, patchPath := getLocalOutputPaths(branchName)
+
+	for _, item := range []string{summaryPath, patchPath} {
+		if err := os.Remove(item); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	if comment != "" {
+		slog.Info(fmt.Sprintf("Closed branch %s: %s", branchName, comment))
+	}
+
+	return nil
+}
+
+func (env LocalCiEnv) WriteBuildPackages(packages map[string][]string) error {
+	printBuildPackages(packages)
+
+	return nil
+}
+
+func (env LocalCiEnv) SetGitCommitOptions(options *git.CommitOptions) error {
+	if name := os.Getenv("GIT_AUTHOR_NAME"); name != "" {
+		options.Author = &gitobject.Signature{
+			Name:  name,
+			Email: os.Getenv("GIT_AUTHOR_EMAIL"),
+		}
+	}
+
+	return nil
+}
+
+func (env LocalCiEnv) SetGitPushOptions(options *git.PushOptions) error {
+	return nil
+}
+
+func getLocalOutputPaths(branchName string) (string, string) {
+	name := strings.ReplaceAll(branchName, "/", "-")
+	outputPath := config.GetLocalOutputPath()
+
+	return filepath.Join(outputPath, fmt.Sprintf("%s.md", name)), filepath.Join(outputPath, fmt.Sprintf("%s.patch", name))
+}
+
+func formatPatch(branchName string) ([]byte, error) {
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+
+	if err != nil {
+		return nil, err
+	}
+
+	baseHash, err := repo.ResolveRevision(plumbing.Revision(config.GetBaseBranch()))
+
+	if err != nil {
+		return nil, err
+	}
+
+	ref, err := repo.Reference(plumbing.NewBranchReferenceName(branchName), true)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []*gitobject.Commit
+
+	for hash := ref.Hash(); hash != *baseHash; {
+		commit, err := repo.CommitObject(hash)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if commit.NumParents() == 0 {
+			return nil, errors.New(fmt.Sprintf("branch %s is not based on %s", branchName, config.GetBaseBranch()))
+		}
+
+		commits = append([]*gitobject.Commit{commit}, commits...)
+		hash = commit.ParentHashes[0]
+	}
+
+	buf := bytes.Buffer{}
+
+	for index, commit := range commits {
+		parent, err := commit.Parent(0)
+
+		if err != nil {
+			return nil, err
+		}
+
+		patch, err := parent.Patch(commit)
+
+		if err != nil {
+			return nil, err
+		}
+
+		subject, message, _ := strings.Cut(strings.TrimSpace(commit.Message), "\n")
+		prefix := "[PATCH]"
+
+		if len(commits) > 1 {
+			prefix = fmt.Sprintf("[PATCH %d/%d]", index+1, len(commits))
+		}
+
+		buf.WriteString(fmt.Sprintf("From %s Mon Sep 17 00:00:00 2001\n", commit.Hash))
+		buf.WriteString(fmt.Sprintf("From: %s <%s>\n", commit.Author.Name, commit.Author.Email))
+		buf.WriteString(fmt.Sprintf("Date: %s\n", commit.Author.When.Format("Mon, 2 Jan 2006 15:04:05 -0700")))
+		buf.WriteString(fmt.Sprintf("Subject: %s %s\n\n", prefix, subject))
+
+		if message = strings.TrimSpace(message); message != "" {
+			buf.WriteString(fmt.Sprintf("%s\n", message))
+		}
+
+		buf.WriteString(fmt.Sprintf("---\n%s\n", patch.Stats()))
+		buf.WriteString(patch.String())
+		buf.WriteString("-- \naur-builder\n\n")
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,63 @@
+package cienv
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/ryanpetris/aur-builder/config"
+	"strings"
+)
+
+func getRemoteRepository(remoteName string) (string, error) {
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+
+	if err != nil {
+		return "", err
+	}
+
+	remote, err := repo.Remote(remoteName)
+
+	if err != nil {
+		return "", err
+	}
+
+	url := remote.Config().URLs[0]
+
+	if index := strings.Index(url, "://"); index >= 0 {
+		url = url[index+3:]
+
+		if index := strings.Index(url, "/"); index >= 0 {
+			url = url[index+1:]
+		}
+	} else if index := strings.Index(url, ":"); index >= 0 {
+		url = url[index+1:]
+	}
+
+	url = strings.TrimSuffix(strings.Trim(url, "/"), ".git")
+
+	if strings.Count(url, "/") < 1 {
+		return "", errors.New(fmt.Sprintf("could not determine repository for remote %s", remoteName))
+	}
+
+	return url, nil
+}
+
+func isForkRemote() bool {
+	return config.GetPushRemote() != config.GetTargetRemote()
+}
+
+func getTargetRepository(defaultRepository string) (string, error) {
+	if isForkRemote() {
+		return getRemoteRepository(config.GetTargetRemote())
+	}
+
+	return defaultRepository, nil
+}
+
+func getHeadRepository(targetRepository string) (string, error) {
+	if isForkRemote() {
+		return getRemoteRepository(config.GetPushRemote())
+	}
+
+	return targetRepository, nil
+}
@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"github.com/ryanpetris/aur-builder/cienv"
+	"github.com/ryanpetris/aur-builder/config"
 	"github.com/ryanpetris/aur-builder/git"
 	"github.com/ryanpetris/aur-builder/pkg"
 	"log/slog"
@@ -14,38 +15,41 @@ import (
 func BumpPkgrel(args []string) {
 	cmd := flag.NewFlagSet("bump-pkgrel", flag.ExitOnError)
 
-	cmdPackages := cmd.String("packages", "", "comma-separated list of packages to bump")
+	selector := addSelectionFlags(cmd, true)
+	cmdPackages := cmd.String("packages", "", "comma-separated list of package names (not pkgbase) to bump")
 
 	if err := cmd.Parse(args[1:]); err != nil {
 		panic(err)
 	}
 
-	packages := strings.Split(*cmdPackages, ",")
+	var packages []string
 
-	if len(packages) == 0 {
-		panic("--packages is required")
+	for _, pkgname := range strings.Split(*cmdPackages, ",") {
+		if pkgname = strings.TrimSpace(pkgname); pkgname != "" {
+			packages = append(packages, pkgname)
+		}
+	}
+
+	if len(packages) == 0 && selector.IsEmpty() {
+		exitWithFailure(cmd.Name(), "--packages or a package selection is required")
 	}
 
 	cenv := cienv.FindCiEnv()
 	var bumpPkgbase []string
+	var matchedPkgnames []string
 
-	allPackages, err := pkg.GetPackages()
-
-	if err != nil {
-		panic(err)
-	}
+	for _, pkgbase := range selectPackages(cmd.Name(), selector) {
+		if len(packages) == 0 {
+			bumpPkgbase = append(bumpPkgbase, pkgbase)
+			continue
+		}
 
-	for _, pkgbase := range allPackages {
 		pconfig, err := pkg.LoadConfig(pkgbase)
 
 		if err != nil {
 			panic(err)
 		}
 
-		if pconfig.Ignore {
-			continue
-		}
-
 		if err := pconfig.Merge(pkgbase, true); err != nil {
 			panic(err)
 		}
@@ -61,12 +65,26 @@ func BumpPkgrel(args []string) {
 				continue
 			}
 
-			bumpPkgbase = append(bumpPkgbase, pkgbase)
-			break
+			matchedPkgnames = append(matchedPkgnames, pkgname)
+
+			if !slices.Contains(bumpPkgbase, pkgbase) {
+				bumpPkgbase = append(bumpPkgbase, pkgbase)
+			}
+		}
+	}
+
+	for _, pkgname := range packages {
+		if !slices.Contains(matchedPkgnames, pkgname) {
+			exitWithFailure(cmd.Name(), fmt.Sprintf("--packages %s does not match any packages", pkgname))
 		}
 	}
 
+	currentPkgbase := ""
+
+	defer notifyOnPanic("bump-pkgrel", &currentPkgbase)
+
 	for _, pkgbase := range bumpPkgbase {
+		currentPkgbase = pkgbase
 		pconfig, err := pkg.LoadConfig(pkgbase)
 
 		if err != nil {
@@ -77,6 +95,12 @@ func BumpPkgrel(args []string) {
 			panic(err)
 		}
 
+		before, err := pconfig.ReadPackageSnapshot(pkgbase)
+
+		if err != nil {
+			panic(err)
+		}
+
 		upstreamEpoch, mergedPkgver, mergedPkgrel, mergedSubpkgrel, err := pkg.GetMergedVersionParts(pkgbase)
 
 		if err != nil {
@@ -110,36 +134,57 @@ func BumpPkgrel(args []string) {
 
 		slog.Info(fmt.Sprintf("Updating package %s", pkgbase))
 
-		if cenv.IsCI() {
-			if err := git.CreateAndSwitchToPackageUpdateBranch(pkgbase, branchVersion); err != nil {
+		modifyPackage(cenv, pkgbase, func() {
+			if err := pconfig.Write(pkgbase); err != nil {
 				panic(err)
 			}
-		}
 
-		if err := pconfig.Write(pkgbase); err != nil {
-			panic(err)
-		}
+			if cenv.IsCI() {
+				if err := pconfig.Merge(pkgbase, true); err != nil {
+					panic(err)
+				}
 
-		if cenv.IsCI() {
-			if err := git.AddAll(); err != nil {
-				panic(err)
-			}
+				message, err := config.RenderCommitMessage("Update", pkgbase, branchVersion)
 
-			if err := git.Commit(fmt.Sprintf("Update %s at version %s", pkgbase, branchVersion)); err != nil {
-				panic(err)
-			}
+				if err != nil {
+					panic(err)
+				}
 
-			if err := git.PushPackageBranch(pkgbase, branchVersion); err != nil {
-				panic(err)
-			}
+				title, err := config.RenderPrTitle("Update", pkgbase, branchVersion)
 
-			if err := cenv.CreatePR(); err != nil {
-				panic(err)
-			}
+				if err != nil {
+					panic(err)
+				}
 
-			if err := git.SwitchToMaster(); err != nil {
-				panic(err)
+				body, err := pconfig.GeneratePrBody(pkgbase, before)
+
+				if err != nil {
+					panic(err)
+				}
+
+				if err := git.CommitPackage(pkgbase, branchVersion, message); err != nil {
+					panic(err)
+				}
+
+				if err := git.PushPackageBranch(pkgbase, branchVersion); err != nil {
+					panic(err)
+				}
+
+				branchName, err := git.GetPackageBranchName(pkgbase, branchVersion)
+
+				if err != nil {
+					panic(err)
+				}
+
+				prUrl, err := cenv.CreatePR(branchName, title, body, config.GetPrLabels())
+
+				if err != nil {
+					panic(err)
+				}
+
+				notifyPullRequest(pkgbase, branchVersion, title, prUrl)
+				supersedePackageBranches(cenv, pkgbase, branchVersion, prUrl)
 			}
-		}
+		})
 	}
 }
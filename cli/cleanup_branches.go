@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"github.com/ryanpetris/aur-builder/cienv"
+	"github.com/ryanpetris/aur-builder/git"
+	"github.com/ryanpetris/aur-builder/pkg"
+	"log/slog"
+)
+
+func CleanupBranchesMain(args []string) {
+	cmd := flag.NewFlagSet("cleanup-branches", flag.ExitOnError)
+
+	selector := addSelectionFlags(cmd, true)
+	cmdDryRun := cmd.Bool("dry-run", false, "show branches that would be removed without removing them")
+
+	if err := cmd.Parse(args[1:]); err != nil {
+		panic(err)
+	}
+
+	cenv := cienv.FindCiEnv()
+	allPackages, err := pkg.GetPackages()
+
+	if err != nil {
+		panic(err)
+	}
+
+	packageBranches, err := git.GetPackageBranches(allPackages)
+
+	if err != nil {
+		panic(err)
+	}
+
+	for _, pkgbase := range selectPackages(cmd.Name(), selector) {
+		for _, branchName := range packageBranches[pkgbase] {
+			state, err := cenv.GetPRState(branchName)
+
+			if err != nil {
+				panic(err)
+			}
+
+			if state != cienv.PrStateMerged && state != cienv.PrStateClosed {
+				continue
+			}
+
+			if *cmdDryRun {
+				fmt.Printf("would remove %s (%s)\n", branchName, state)
+				continue
+			}
+
+			slog.Info(fmt.Sprintf("Removing branch %s (%s)", branchName, state))
+
+			if err := git.DeleteRemoteBranch(branchName); err != nil {
+				panic(err)
+			}
+		}
+	}
+}
+
+func supersedePackageBranches(cenv cienv.CiEnv, pkgbase string, pkgver string, prUrl string) {
+	allPackages, err := pkg.GetPackages()
+
+	if err != nil {
+		panic(err)
+	}
+
+	branchName, err := git.GetPackageBranchName(pkgbase, pkgver)
+
+	if err != nil {
+		panic(err)
+	}
+
+	packageBranches, err := git.GetPackageBranches(allPackages)
+
+	if err != nil {
+		panic(err)
+	}
+
+	supersededBranches, err := git.GetSupersededBranches(pkgbase, pkgver, packageBranches[pkgbase])
+
+	if err != nil {
+		panic(err)
+	}
+
+	for _, oldBranchName := range supersededBranches {
+		slog.Info(fmt.Sprintf("Superseding branch %s with %s", oldBranchName, branchName))
+
+		comment := fmt.Sprintf("Superseded by %s.", branchName)
+
+		if prUrl != "" {
+			comment = fmt.Sprintf("Superseded by %s.", prUrl)
+		}
+
+		if err := cenv.ClosePR(oldBranchName, comment); err != nil {
+			panic(err)
+		}
+
+		if err := git.DeleteRemoteBranch(oldBranchName); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func supersedeMaintainerBranches(cenv cienv.CiEnv, pkgbase string, maintainer string, branchName string, prUrl string) {
+	allPackages, err := pkg.GetPackages()
+
+	if err != nil {
+		panic(err)
+	}
+
+	packageBranches, err := git.GetPackageBranches(allPackages)
+
+	if err != nil {
+		panic(err)
+	}
+
+	supersededBranches, err := git.GetSupersededMaintainerBranches(pkgbase, maintainer, packageBranches[pkgbase])
+
+	if err != nil {
+		panic(err)
+	}
+
+	for _, oldBranchName := range supersededBranches {
+		slog.Info(fmt.Sprintf("Superseding branch %s with %s", oldBranchName, branchName))
+
+		if err := cenv.ClosePR(oldBranchName, fmt.Sprintf("Superseded by %s.", prUrl)); err != nil {
+			panic(err)
+		}
+
+		if err := git.DeleteRemoteBranch(oldBranchName); err != nil {
+			panic(err)
+		}
+	}
+}
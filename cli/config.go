@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/ryanpetris/aur-builder/config"
+	"gopkg.in/yaml.v3"
+	"os"
+)
+
+func ConfigMain(args []string) {
+	if len(args) < 2 {
+		fmt.Println("invalid config command")
+		os.Exit(1)
+	}
+
+	switch args[1] {
+	case "show":
+		configShow()
+
+	default:
+		fmt.Println("invalid config command")
+		os.Exit(1)
+	}
+}
+
+func configShow() {
+	values, err := config.GetGlobalConfig().GetEffectiveValues()
+
+	if err != nil {
+		panic(err)
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode}
+
+	for _, value := range values {
+		keyNode := &yaml.Node{Kind: yaml.ScalarNode, Value: value.Key}
+		valueNode := &yaml.Node{}
+
+		if err := valueNode.Encode(value.Value); err != nil {
+			panic(err)
+		}
+
+		if valueNode.Kind == yaml.ScalarNode || len(valueNode.Content) == 0 {
+			valueNode.LineComment = value.Source
+		} else {
+			keyNode.LineComment = value.Source
+		}
+
+		root.Content = append(root.Content, keyNode, valueNode)
+	}
+
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(root); err != nil {
+		panic(err)
+	}
+}
@@ -8,27 +8,13 @@ import (
 func FormatConfigMain(args []string) {
 	cmd := flag.NewFlagSet("formatconfig", flag.ExitOnError)
 
-	cmdPackage := cmd.String("formatconfig", "", "name of package to format the config.yaml file for")
+	selector := addSelectionFlags(cmd, true)
 
 	if err := cmd.Parse(args[1:]); err != nil {
 		panic(err)
 	}
 
-	var packages []string
-
-	if *cmdPackage != "" {
-		packages = []string{*cmdPackage}
-	} else {
-		pkgs, err := pkg.GetPackages()
-
-		if err != nil {
-			panic(err)
-		}
-
-		packages = pkgs
-	}
-
-	for _, pkgbase := range packages {
+	for _, pkgbase := range selectPackages(cmd.Name(), selector) {
 		if exists, err := pkg.ConfigExists(pkgbase); err != nil {
 			panic(err)
 		} else if !exists {
@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"github.com/ryanpetris/aur-builder/cienv"
+	"github.com/ryanpetris/aur-builder/config"
 	"github.com/ryanpetris/aur-builder/git"
 	"github.com/ryanpetris/aur-builder/impenv"
 	"github.com/ryanpetris/aur-builder/pacman"
@@ -42,6 +43,8 @@ func ImportMain(args []string) {
 
 	pkgbase := strings.ToLower(*cmdPackage)
 
+	defer notifyOnPanic("import", &pkgbase)
+
 	if exists, err := pkg.PackageExists(pkgbase); err != nil {
 		panic(err)
 	} else if exists {
@@ -50,78 +53,91 @@ func ImportMain(args []string) {
 
 	cenv := cienv.FindCiEnv()
 
-	if cenv.IsCI() {
-		if err := git.CreateAndSwitchToPackageUpdateBranch(pkgbase, "0"); err != nil {
-			panic(err)
-		}
-	}
-
 	if exists, err := ienv.PackageExists(pkgbase); err != nil {
 		panic(err)
 	} else if !exists {
 		panic(fmt.Sprintf("Package %s does not exist in source %s", pkgbase, *cmdSource))
 	}
 
-	if err := ienv.PackageImport(pkgbase, ""); err != nil {
-		panic(err)
-	}
-
-	pconfig, err := pkg.LoadConfig(pkgbase)
-
-	if err != nil {
-		panic(err)
-	}
-
-	if updated, err := pconfig.GenVcsInfo(pkgbase); err != nil {
-		panic(err)
-	} else if updated {
-		if err := pconfig.Write(pkgbase); err != nil {
+	modifyPackage(cenv, pkgbase, func() {
+		if err := ienv.PackageImport(pkgbase, ""); err != nil {
 			panic(err)
 		}
-	}
 
-	if err := pconfig.ClearMerge(pkgbase); err != nil {
-		panic(err)
-	}
-
-	if cenv.IsCI() {
-		if err := pconfig.Merge(pkgbase, false); err != nil {
-			panic(err)
-		}
-
-		pkginfo, err := pacman.LoadPkgInfo(pkgbase)
-		pkgver := pkginfo.GetFullVersion()
+		pconfig, err := pkg.LoadConfig(pkgbase)
 
 		if err != nil {
 			panic(err)
 		}
 
-		if err := git.CreateAndSwitchToPackageUpdateBranch(pkgbase, pkgver); err != nil {
+		if updated, err := pconfig.GenVcsInfo(pkgbase); err != nil {
 			panic(err)
+		} else if updated {
+			if err := pconfig.Write(pkgbase); err != nil {
+				panic(err)
+			}
 		}
 
-		if err := git.AddAll(); err != nil {
+		if err := pconfig.ClearMerge(pkgbase); err != nil {
 			panic(err)
 		}
 
-		if err := git.Commit(fmt.Sprintf("Add %s at version %s", pkgbase, pkgver)); err != nil {
-			panic(err)
-		}
+		if cenv.IsCI() {
+			if err := pconfig.Merge(pkgbase, false); err != nil {
+				panic(err)
+			}
 
-		if err := git.PushPackageBranch(pkgbase, pkgver); err != nil {
-			panic(err)
-		}
+			pkginfo, err := pacman.LoadPkgInfo(pkgbase)
+			pkgver := pkginfo.GetFullVersion()
 
-		if err := cenv.CreatePR(); err != nil {
-			panic(err)
-		}
+			if err != nil {
+				panic(err)
+			}
 
-		if err := git.SwitchToMaster(); err != nil {
-			panic(err)
-		}
-	} else {
-		if err := pconfig.ClearMerge(pkgbase); err != nil {
-			panic(err)
+			message, err := config.RenderCommitMessage("Add", pkgbase, pkgver)
+
+			if err != nil {
+				panic(err)
+			}
+
+			title, err := config.RenderPrTitle("Add", pkgbase, pkgver)
+
+			if err != nil {
+				panic(err)
+			}
+
+			body, err := pconfig.GeneratePrBody(pkgbase, nil)
+
+			if err != nil {
+				panic(err)
+			}
+
+			if err := git.CommitPackage(pkgbase, pkgver, message); err != nil {
+				panic(err)
+			}
+
+			if err := git.PushPackageBranch(pkgbase, pkgver); err != nil {
+				panic(err)
+			}
+
+			branchName, err := git.GetPackageBranchName(pkgbase, pkgver)
+
+			if err != nil {
+				panic(err)
+			}
+
+			prUrl, err := cenv.CreatePR(branchName, title, body, config.GetPrLabels())
+
+			if err != nil {
+				panic(err)
+			}
+
+			notifyPullRequest(pkgbase, pkgver, title, prUrl)
+			supersedePackageBranches(cenv, pkgbase, pkgver, prUrl)
+		} else {
+			if err := pconfig.ClearMerge(pkgbase); err != nil {
+				panic(err)
+			}
 		}
-	}
+	})
 }
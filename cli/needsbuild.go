@@ -1,23 +1,71 @@
 package cli
 
 import (
+	"flag"
 	"fmt"
 	"github.com/ryanpetris/aur-builder/arch"
 	"github.com/ryanpetris/aur-builder/cienv"
+	"github.com/ryanpetris/aur-builder/config"
 	"github.com/ryanpetris/aur-builder/misc"
+	"github.com/ryanpetris/aur-builder/notify"
 	"github.com/ryanpetris/aur-builder/pacman"
 	"github.com/ryanpetris/aur-builder/pkg"
 	"log/slog"
+	"slices"
+	"strings"
 )
 
 func NeedsBuildMain(args []string) {
-	trackers := map[string]misc.PackageTracker{}
-	allPackages, err := pkg.GetPackages()
+	cmd := flag.NewFlagSet("needs-build", flag.ExitOnError)
+
+	selector := addSelectionFlags(cmd, true)
+
+	if err := cmd.Parse(args[1:]); err != nil {
+		panic(err)
+	}
+
+	allPackages := selectPackages(cmd.Name(), selector)
+	buildPackages := map[string][]string{}
+	repositories := config.GetRepositories()
+
+	if len(repositories) == 0 {
+		buildPackages[""] = getBuildPackages(allPackages, nil)
+	}
+
+	for _, repository := range repositories {
+		buildPackages[repository.Name] = getBuildPackages(allPackages, repository)
+	}
+
+	cenv := cienv.FindCiEnv()
+	err := cenv.WriteBuildPackages(buildPackages)
 
 	if err != nil {
 		panic(err)
 	}
 
+	for repository, updatePackages := range buildPackages {
+		if len(updatePackages) == 0 {
+			continue
+		}
+
+		message := fmt.Sprintf("%d packages need to be built: %s", len(updatePackages), strings.Join(updatePackages, ", "))
+
+		if repository != "" {
+			message = fmt.Sprintf("%d packages need to be built in repository %s: %s", len(updatePackages), repository, strings.Join(updatePackages, ", "))
+		}
+
+		notify.Notify(&notify.Event{
+			Type:       notify.EventNeedsBuild,
+			Repository: repository,
+			Packages:   updatePackages,
+			Message:    message,
+		})
+	}
+}
+
+func getBuildPackages(allPackages []string, repository *config.RepositoryConfig) []string {
+	trackers := map[string]misc.PackageTracker{}
+
 	for _, pkgbase := range allPackages {
 		pconfig, err := pkg.LoadConfig(pkgbase)
 
@@ -30,25 +78,28 @@ func NeedsBuildMain(args []string) {
 			continue
 		}
 
-		tracker := misc.PackageTracker{
-			Pkgbase: pkgbase,
+		if repository != nil && !pconfig.InRepository(repository.Name) {
+			continue
 		}
 
-		tracker.UpstreamVersion, err = pkg.GetMergedVersion(pkgbase)
+		version, pkginfo, err := loadMergedPackage(pconfig, pkgbase, repository)
 
 		if err != nil {
 			panic(err)
 		}
 
-		pkginfo, err := pacman.LoadPkgInfo(pkgbase)
-
-		if err != nil {
-			panic(err)
+		tracker := misc.PackageTracker{
+			Pkgbase:         pkgbase,
+			UpstreamVersion: version,
 		}
 
 		for _, pkgname := range pkginfo.Pkgname {
 			if tracker.RepositoryVersion == "" {
-				tracker.RepositoryVersion, _ = arch.GetPackageVersion(pkgname)
+				if repository == nil {
+					tracker.RepositoryVersion, _ = arch.GetPackageVersion(pkgname)
+				} else {
+					tracker.RepositoryVersion, _ = arch.GetRepositoryPackageVersion(repository.GetDb(), pkgname)
+				}
 			}
 
 			tracker.Packages = append(tracker.Packages, misc.PackageInfo{
@@ -62,6 +113,16 @@ func NeedsBuildMain(args []string) {
 		tracker.NeedsUpdate, err = pacman.IsVersionNewer(tracker.RepositoryVersion, tracker.UpstreamVersion)
 
 		if err != nil {
+			message := fmt.Sprintf("Unable to compare versions for package %s, skipping: %s", pkgbase, err)
+			slog.Error(message)
+
+			notify.Notify(&notify.Event{
+				Type:       notify.EventFailure,
+				Pkgbase:    pkgbase,
+				Repository: getRepositoryName(repository),
+				Message:    message,
+			})
+
 			continue
 		}
 
@@ -76,7 +137,7 @@ func NeedsBuildMain(args []string) {
 		trackers[pkgbase] = tracker
 	}
 
-	var updatePackages []string
+	updatePackages := []string{}
 
 	for pkgbase, tracker := range trackers {
 		if !tracker.NeedsUpdate {
@@ -104,10 +165,61 @@ func NeedsBuildMain(args []string) {
 		}
 	}
 
-	cenv := cienv.FindCiEnv()
-	err = cenv.WriteBuildPackages(updatePackages)
+	slices.Sort(updatePackages)
+
+	return updatePackages
+}
+
+func getRepositoryName(repository *config.RepositoryConfig) string {
+	if repository == nil {
+		return ""
+	}
+
+	return repository.Name
+}
+
+// loadMergedPackage reads the version and package info of a package from its
+// merged folder. For a repository, the repository's overrides are merged in
+// place of the folder prepare created, which is put back afterwards untouched.
+func loadMergedPackage(pconfig *pkg.PackageConfig, pkgbase string, repository *config.RepositoryConfig) (version string, pkginfo *pacman.PkgInfo, err error) {
+	if repository != nil {
+		var rconfig *pkg.PackageConfig
+		var restore func() error
+
+		rconfig, err = pconfig.ForRepository(pkgbase, repository.Name)
+
+		if err != nil {
+			return "", nil, err
+		}
+
+		restore, err = pkg.SaveMerge(pkgbase)
+
+		if err != nil {
+			return "", nil, err
+		}
+
+		defer func() {
+			if restoreErr := restore(); restoreErr != nil && err == nil {
+				err = restoreErr
+			}
+		}()
+
+		if err := rconfig.Merge(pkgbase, true); err != nil {
+			return "", nil, err
+		}
+	}
+
+	version, err = pkg.GetMergedVersion(pkgbase)
 
 	if err != nil {
-		panic(err)
+		return "", nil, err
 	}
+
+	pkginfo, err = pacman.LoadPkgInfo(pkgbase)
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	return version, pkginfo, nil
 }
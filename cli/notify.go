@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/ryanpetris/aur-builder/notify"
+	"log/slog"
+	"os"
+)
+
+func notifyOnPanic(command string, pkgbase *string) {
+	if r := recover(); r != nil {
+		message := fmt.Sprintf("%s failed: %v", command, r)
+
+		if *pkgbase != "" {
+			message = fmt.Sprintf("%s failed for package %s: %v", command, *pkgbase, r)
+		}
+
+		notify.Notify(&notify.Event{
+			Type:    notify.EventFailure,
+			Pkgbase: *pkgbase,
+			Message: message,
+		})
+
+		panic(r)
+	}
+}
+
+func notifyPullRequest(pkgbase string, version string, title string, prUrl string) {
+	notify.Notify(&notify.Event{
+		Type:    notify.EventUpdate,
+		Pkgbase: pkgbase,
+		Version: version,
+		Url:     prUrl,
+		Message: title,
+	})
+}
+
+// exitWithFailure reports a failed command and exits. Deferred calls don't run
+// on os.Exit, so pending notifications are flushed first.
+func exitWithFailure(command string, message string) {
+	slog.Error(message)
+
+	notify.Notify(&notify.Event{
+		Type:    notify.EventFailure,
+		Message: fmt.Sprintf("%s failed: %s", command, message),
+	})
+
+	notify.Flush()
+	os.Exit(1)
+}
@@ -1,46 +1,43 @@
 package cli
 
 import (
+	"errors"
 	"flag"
+	"fmt"
+	"github.com/ryanpetris/aur-builder/config"
 	"github.com/ryanpetris/aur-builder/pkg"
+	"log/slog"
 	"sync"
 )
 
 func PrepareMain(args []string) {
 	cmd := flag.NewFlagSet("prepare", flag.ExitOnError)
 
-	cmdPackage := cmd.String("package", "", "name of package to prepare")
+	selector := addSelectionFlags(cmd, true)
 	cmdNoVcs := cmd.Bool("no-vcs", false, "don't process vcs overrides")
+	cmdRepository := cmd.String("repository", "", "name of repository whose overrides to apply")
 
 	if err := cmd.Parse(args[1:]); err != nil {
 		panic(err)
 	}
 
-	var packages []string
+	packages := selectPackages(cmd.Name(), selector)
 
-	if *cmdPackage != "" {
-		packages = []string{*cmdPackage}
-	} else {
-		pkgs, err := pkg.GetPackages()
-
-		if err != nil {
-			panic(err)
-		}
-
-		packages = pkgs
+	if *cmdRepository != "" && config.GetRepository(*cmdRepository) == nil {
+		panic(errors.New(fmt.Sprintf("repository %s is not configured", *cmdRepository)))
 	}
 
 	var wg sync.WaitGroup
 
 	for _, pkgbase := range packages {
 		wg.Add(1)
-		go processPackage(pkgbase, &wg, !*cmdNoVcs)
+		go processPackage(pkgbase, &wg, !*cmdNoVcs, *cmdRepository)
 	}
 
 	wg.Wait()
 }
 
-func processPackage(pkgbase string, wg *sync.WaitGroup, processVcs bool) {
+func processPackage(pkgbase string, wg *sync.WaitGroup, processVcs bool, repository string) {
 	defer wg.Done()
 	pconfig, err := pkg.LoadConfig(pkgbase)
 
@@ -48,6 +45,19 @@ func processPackage(pkgbase string, wg *sync.WaitGroup, processVcs bool) {
 		panic(err)
 	}
 
+	if repository != "" {
+		if !pconfig.InRepository(repository) {
+			slog.Info(fmt.Sprintf("Skipping %s, not in repository %s.", pkgbase, repository))
+			return
+		}
+
+		pconfig, err = pconfig.ForRepository(pkgbase, repository)
+
+		if err != nil {
+			panic(err)
+		}
+	}
+
 	if err := pconfig.Merge(pkgbase, processVcs); err != nil {
 		panic(err)
 	}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/ryanpetris/aur-builder/cienv"
+	"github.com/ryanpetris/aur-builder/git"
+	"log/slog"
+)
+
+// modifyPackage runs modify, which changes the files of a package. In CI the
+// package is restored to the base branch afterwards, even if modify panics,
+// so a failed update doesn't leave the checkout dirty for later steps.
+func modifyPackage(cenv cienv.CiEnv, pkgbase string, modify func()) {
+	if cenv.IsCI() {
+		defer restorePackage(pkgbase)
+	}
+
+	modify()
+}
+
+func restorePackage(pkgbase string) {
+	err := git.RestorePackage(pkgbase)
+
+	if err == nil {
+		return
+	}
+
+	if r := recover(); r != nil {
+		slog.Error(fmt.Sprintf("Unable to restore package %s: %s", pkgbase, err))
+		panic(r)
+	}
+
+	panic(err)
+}
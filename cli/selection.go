@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"flag"
+	"github.com/ryanpetris/aur-builder/pkg"
+	"strings"
+)
+
+type stringsFlag []string
+
+func (value *stringsFlag) String() string {
+	return strings.Join(*value, ",")
+}
+
+func (value *stringsFlag) Set(item string) error {
+	*value = append(*value, item)
+
+	return nil
+}
+
+func addSelectionFlags(cmd *flag.FlagSet, withSource bool) *pkg.PackageSelector {
+	selector := &pkg.PackageSelector{}
+
+	cmd.Var((*stringsFlag)(&selector.Packages), "package", "name or glob of package to select; can be repeated")
+	cmd.Var((*stringsFlag)(&selector.Tags), "tag", "select packages with this tag; can be repeated")
+	cmd.Var((*stringsFlag)(&selector.Excludes), "exclude", "name or glob of package to exclude; can be repeated")
+	cmd.Var((*stringsFlag)(&selector.Filters), "select", "select packages matching an expression such as source=aur,tag=kde,!tag=broken; can be repeated")
+
+	if withSource {
+		cmd.Var((*stringsFlag)(&selector.Sources), "source", "select packages from this source; can be repeated")
+	}
+
+	return selector
+}
+
+func selectPackages(command string, selector *pkg.PackageSelector) []string {
+	packages, err := selector.Select()
+
+	if err != nil {
+		exitWithFailure(command, err.Error())
+	}
+
+	return packages
+}
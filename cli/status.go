@@ -0,0 +1,233 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/ryanpetris/aur-builder/impenv"
+	"github.com/ryanpetris/aur-builder/misc"
+	"github.com/ryanpetris/aur-builder/pacman"
+	"github.com/ryanpetris/aur-builder/pkg"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+type packageStatus struct {
+	Pkgbase   string
+	Source    string
+	Version   string
+	Available string
+	Status    string
+	Held      bool
+}
+
+func StatusMain(args []string) {
+	cmd := flag.NewFlagSet("status", flag.ExitOnError)
+
+	selector := addSelectionFlags(cmd, true)
+	cmdHeld := cmd.Bool("held", false, "only show held packages")
+	cmdOffline := cmd.Bool("offline", false, "don't look up available versions")
+
+	if err := cmd.Parse(args[1:]); err != nil {
+		panic(err)
+	}
+
+	packages := selectPackages(cmd.Name(), selector)
+	available := map[string]map[string]misc.PackageTracker{}
+
+	if !*cmdOffline {
+		available = getAvailableVersions(packages)
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "PKGBASE\tSOURCE\tVERSION\tAVAILABLE\tSTATUS")
+
+	for _, pkgbase := range packages {
+		status := getPackageStatus(pkgbase, available, *cmdOffline)
+
+		if *cmdHeld && !status.Held {
+			continue
+		}
+
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n", status.Pkgbase, status.Source, status.Version, status.Available, status.Status)
+	}
+
+	if err := writer.Flush(); err != nil {
+		panic(err)
+	}
+}
+
+func getPackageStatus(pkgbase string, available map[string]map[string]misc.PackageTracker, offline bool) *packageStatus {
+	pconfig, err := pkg.LoadConfig(pkgbase)
+
+	if err != nil {
+		panic(err)
+	}
+
+	status := &packageStatus{
+		Pkgbase:   pkgbase,
+		Source:    pconfig.Source,
+		Version:   "-",
+		Available: "-",
+	}
+
+	if status.Source == "" {
+		status.Source = "-"
+	}
+
+	if version, err := getCurrentVersion(pkgbase, pconfig); err != nil {
+		slog.Warn(fmt.Sprintf("Unable to read version of package %s: %s", pkgbase, err))
+	} else {
+		status.Version = version
+	}
+
+	hold, active, err := pconfig.GetHold()
+
+	if err != nil {
+		panic(errors.New(fmt.Sprintf("invalid update policy for package %s: %s", pkgbase, err)))
+	}
+
+	if pconfig.Ignore {
+		status.Status = "ignored"
+		return status
+	}
+
+	ienv := getImportEnv(pconfig.Source)
+	tracker, found := available[pconfig.Source][pkgbase]
+	var messages []string
+
+	switch {
+	case offline || ienv == nil:
+	case !found:
+		messages = append(messages, fmt.Sprintf("not found in %s", pconfig.Source))
+	case !tracker.NeedsUpdate:
+		status.Available = tracker.RepositoryVersion
+		messages = append(messages, "up to date")
+	default:
+		status.Available = tracker.RepositoryVersion
+
+		if allowed, reason := checkUpdatePolicy(ienv, tracker); allowed {
+			messages = append(messages, "update available")
+		} else {
+			messages = append(messages, fmt.Sprintf("held: %s", reason))
+			status.Held = true
+		}
+	}
+
+	if hold != nil && !status.Held {
+		if active {
+			messages = append(messages, hold.String())
+			status.Held = true
+		} else {
+			messages = append(messages, fmt.Sprintf("hold expired on %s", hold.Until))
+		}
+	}
+
+	status.Status = "-"
+
+	if len(messages) > 0 {
+		status.Status = strings.Join(messages, "; ")
+	}
+
+	return status
+}
+
+func getCurrentVersion(pkgbase string, pconfig *pkg.PackageConfig) (string, error) {
+	switch pconfig.Source {
+	case "aur", "arch":
+		return pkg.GetUpstreamVersion(pkgbase)
+	default:
+		return pkg.GetLocalVersion(pkgbase)
+	}
+}
+
+func getImportEnv(source string) impenv.ImportEnv {
+	switch source {
+	case "aur":
+		return impenv.AurImportEnv{}
+	case "arch":
+		return impenv.ArchImportEnv{}
+	case "local":
+		return impenv.LocalImportEnv{}
+	default:
+		return nil
+	}
+}
+
+func getAvailableVersions(packages []string) map[string]map[string]misc.PackageTracker {
+	pkgnames := map[string][]string{}
+	currentVersions := map[string]string{}
+
+	for _, pkgbase := range packages {
+		pconfig, err := pkg.LoadConfig(pkgbase)
+
+		if err != nil {
+			panic(err)
+		}
+
+		ienv := getImportEnv(pconfig.Source)
+
+		if pconfig.Ignore || ienv == nil {
+			continue
+		}
+
+		var names []string
+
+		if ienv.IsLocalEnv() {
+			names, err = pkg.GetLocalPkgnames(pkgbase)
+		} else {
+			names, err = pkg.GetUpstreamPkgnames(pkgbase)
+		}
+
+		if err != nil {
+			slog.Warn(fmt.Sprintf("Unable to read package names of package %s: %s", pkgbase, err))
+			continue
+		}
+
+		if currentVersions[pkgbase], err = getCurrentVersion(pkgbase, pconfig); err != nil {
+			slog.Warn(fmt.Sprintf("Unable to read version of package %s: %s", pkgbase, err))
+			continue
+		}
+
+		pkgnames[pconfig.Source] = append(pkgnames[pconfig.Source], names...)
+	}
+
+	result := map[string]map[string]misc.PackageTracker{}
+
+	for source, names := range pkgnames {
+		pkginfos, err := getImportEnv(source).GetPackageInfo(names)
+
+		if err != nil {
+			panic(err)
+		}
+
+		result[source] = map[string]misc.PackageTracker{}
+
+		for _, pkginfo := range pkginfos {
+			tracker, hasKey := result[source][pkginfo.Pkgbase]
+
+			if hasKey {
+				tracker.Packages = append(tracker.Packages, pkginfo)
+			} else {
+				tracker = misc.PackageTracker{
+					Pkgbase:           pkginfo.Pkgbase,
+					UpstreamVersion:   currentVersions[pkginfo.Pkgbase],
+					RepositoryVersion: pkginfo.FullVersion,
+					Packages:          []misc.PackageInfo{pkginfo},
+				}
+
+				tracker.NeedsUpdate, err = pacman.IsVersionNewer(tracker.UpstreamVersion, tracker.RepositoryVersion)
+
+				if err != nil {
+					panic(err)
+				}
+			}
+
+			result[source][pkginfo.Pkgbase] = tracker
+		}
+	}
+
+	return result
+}
@@ -1,23 +1,28 @@
 package cli
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"github.com/ryanpetris/aur-builder/cienv"
+	"github.com/ryanpetris/aur-builder/config"
 	"github.com/ryanpetris/aur-builder/git"
 	"github.com/ryanpetris/aur-builder/impenv"
 	"github.com/ryanpetris/aur-builder/misc"
+	"github.com/ryanpetris/aur-builder/notify"
 	"github.com/ryanpetris/aur-builder/pacman"
 	"github.com/ryanpetris/aur-builder/pkg"
 	"log/slog"
 	"slices"
 	"strings"
+	"time"
 )
 
 func UpdateMain(args []string) {
 	cmd := flag.NewFlagSet("update", flag.ExitOnError)
 
 	cmdSource := cmd.String("source", "", "package source (aur, arch)")
+	selector := addSelectionFlags(cmd, false)
 
 	if err := cmd.Parse(args[1:]); err != nil {
 		panic(err)
@@ -28,30 +33,20 @@ func UpdateMain(args []string) {
 	}
 
 	source := strings.ToLower(*cmdSource)
-	var ienv impenv.ImportEnv
-
-	switch source {
-	case "aur":
-		ienv = impenv.AurImportEnv{}
-	case "arch":
-		ienv = impenv.ArchImportEnv{}
-	case "local":
-		ienv = impenv.LocalImportEnv{}
-	default:
+	ienv := getImportEnv(source)
+
+	if ienv == nil {
 		panic(fmt.Sprintf("Invalid source: %s", *cmdSource))
 	}
 
 	cenv := cienv.FindCiEnv()
+	currentPkgbase := ""
 	var updatePkgbase []string
-	var updatePkgname []string
 
-	allPackages, err := pkg.GetPackages()
-
-	if err != nil {
-		panic(err)
-	}
+	defer notifyOnPanic("update", &currentPkgbase)
+	var updatePkgname []string
 
-	for _, pkgbase := range allPackages {
+	for _, pkgbase := range selectPackages(cmd.Name(), selector) {
 		pconfig, err := pkg.LoadConfig(pkgbase)
 
 		if err != nil {
@@ -125,11 +120,22 @@ func UpdateMain(args []string) {
 		}
 	}
 
+	if source == "aur" {
+		checkAurMaintainers(cenv, trackers)
+	}
+
 	for _, tracker := range trackers {
 		if !tracker.NeedsUpdate {
 			continue
 		}
 
+		currentPkgbase = tracker.Pkgbase
+
+		if allowed, reason := checkUpdatePolicy(ienv, tracker); !allowed {
+			slog.Info(fmt.Sprintf("Not updating package %s to version %s: %s", tracker.Pkgbase, tracker.RepositoryVersion, reason))
+			continue
+		}
+
 		if exists, err := git.PackageUpdateBranchExists(tracker.Pkgbase, tracker.RepositoryVersion); err != nil {
 			panic(err)
 		} else if exists {
@@ -138,66 +144,209 @@ func UpdateMain(args []string) {
 		}
 
 		slog.Info(fmt.Sprintf("Updating package %s to version %s", tracker.Pkgbase, tracker.RepositoryVersion))
+		currentPkgbase = tracker.Pkgbase
+
+		var before *pkg.PackageSnapshot
 
 		if cenv.IsCI() {
-			if err := git.CreateAndSwitchToPackageUpdateBranch(tracker.Pkgbase, tracker.RepositoryVersion); err != nil {
-				panic(err)
+			if before, err = pkg.TakePackageSnapshot(tracker.Pkgbase, false); err != nil {
+				slog.Warn(fmt.Sprintf("Could not read current state of package %s: %s", tracker.Pkgbase, err))
 			}
 		}
 
-		if err := ienv.PackageImport(tracker.Pkgbase, tracker.RepositoryVersion); err != nil {
-			panic(err)
-		}
+		modifyPackage(cenv, tracker.Pkgbase, func() {
+			if err := ienv.PackageImport(tracker.Pkgbase, tracker.RepositoryVersion); err != nil {
+				panic(err)
+			}
 
-		pconfig, err := pkg.LoadConfig(tracker.Pkgbase)
+			pconfig, err := pkg.LoadConfig(tracker.Pkgbase)
 
-		if err != nil {
-			panic(err)
-		}
+			if err != nil {
+				panic(err)
+			}
+
+			updated, err := pconfig.GenVcsInfo(tracker.Pkgbase)
+
+			if err != nil {
+				panic(err)
+			}
 
-		if updated, err := pconfig.GenVcsInfo(tracker.Pkgbase); err != nil {
-			panic(err)
-		} else {
 			if !updated && pconfig.Vcs != nil {
 				pconfig.Vcs.Pkgrel += 1
 				updated = true
 			}
 
+			if source == "aur" && pconfig.AurMaintainer != tracker.Packages[0].Maintainer {
+				pconfig.AurMaintainer = tracker.Packages[0].Maintainer
+				updated = true
+			}
+
 			if updated {
 				if err := pconfig.Write(tracker.Pkgbase); err != nil {
 					panic(err)
 				}
 			}
+
+			if err := pconfig.ClearMerge(tracker.Pkgbase); err != nil {
+				panic(err)
+			}
+
+			if cenv.IsCI() {
+				if err := pconfig.Merge(tracker.Pkgbase, false); err != nil {
+					panic(err)
+				}
+
+				message, err := config.RenderCommitMessage("Update", tracker.Pkgbase, tracker.RepositoryVersion)
+
+				if err != nil {
+					panic(err)
+				}
+
+				title, err := config.RenderPrTitle("Update", tracker.Pkgbase, tracker.RepositoryVersion)
+
+				if err != nil {
+					panic(err)
+				}
+
+				body, err := pconfig.GeneratePrBody(tracker.Pkgbase, before)
+
+				if err != nil {
+					panic(err)
+				}
+
+				if err := git.CommitPackage(tracker.Pkgbase, tracker.RepositoryVersion, message); err != nil {
+					panic(err)
+				}
+
+				if err := git.PushPackageBranch(tracker.Pkgbase, tracker.RepositoryVersion); err != nil {
+					panic(err)
+				}
+
+				branchName, err := git.GetPackageBranchName(tracker.Pkgbase, tracker.RepositoryVersion)
+
+				if err != nil {
+					panic(err)
+				}
+
+				prUrl, err := cenv.CreatePR(branchName, title, body, config.GetPrLabels())
+
+				if err != nil {
+					panic(err)
+				}
+
+				notifyPullRequest(tracker.Pkgbase, tracker.RepositoryVersion, title, prUrl)
+				supersedePackageBranches(cenv, tracker.Pkgbase, tracker.RepositoryVersion, prUrl)
+			}
+		})
+	}
+}
+
+func checkAurMaintainers(cenv cienv.CiEnv, trackers map[string]misc.PackageTracker) {
+	var pkgbases []string
+
+	for pkgbase := range trackers {
+		pkgbases = append(pkgbases, pkgbase)
+	}
+
+	slices.Sort(pkgbases)
+
+	for _, pkgbase := range pkgbases {
+		tracker := trackers[pkgbase]
+		maintainer := tracker.Packages[0].Maintainer
+		pconfig, err := pkg.LoadConfig(pkgbase)
+
+		if err != nil {
+			panic(err)
 		}
 
-		if err := pconfig.ClearMerge(tracker.Pkgbase); err != nil {
+		branchVersion := git.GetMaintainerBranchVersion(maintainer)
+
+		changed, err := pconfig.CheckAurMaintainer(maintainer, func() (bool, error) {
+			if !cenv.IsCI() {
+				return false, nil
+			}
+
+			return git.PackageUpdateBranchExists(pkgbase, branchVersion)
+		})
+
+		if err != nil {
 			panic(err)
 		}
 
-		if cenv.IsCI() {
-			if err := pconfig.Merge(tracker.Pkgbase, false); err != nil {
+		if !changed {
+			continue
+		}
+
+		message := pconfig.GetAurMaintainerMessage(pkgbase, maintainer)
+
+		// In CI a first maintainer is recorded with the package's next update.
+		if message == "" && cenv.IsCI() {
+			continue
+		}
+
+		if message != "" {
+			slog.Warn(message)
+
+			notify.Notify(&notify.Event{
+				Type:    notify.EventMaintainer,
+				Pkgbase: pkgbase,
+				Version: tracker.RepositoryVersion,
+				Message: message,
+			})
+		}
+
+		modifyPackage(cenv, pkgbase, func() {
+			pconfig.AurMaintainer = maintainer
+
+			if err := pconfig.Write(pkgbase); err != nil {
 				panic(err)
 			}
 
-			if err := git.AddAll(); err != nil {
-				panic(err)
+			if !cenv.IsCI() {
+				return
 			}
 
-			if err := git.Commit(fmt.Sprintf("Update %s at version %s", tracker.Pkgbase, tracker.RepositoryVersion)); err != nil {
+			title := fmt.Sprintf("Record AUR maintainer of %s", pkgbase)
+
+			if err := git.CommitPackage(pkgbase, branchVersion, title); err != nil {
 				panic(err)
 			}
 
-			if err := git.PushPackageBranch(tracker.Pkgbase, tracker.RepositoryVersion); err != nil {
+			if err := git.PushPackageBranch(pkgbase, branchVersion); err != nil {
 				panic(err)
 			}
 
-			if err := cenv.CreatePR(); err != nil {
+			branchName, err := git.GetPackageBranchName(pkgbase, branchVersion)
+
+			if err != nil {
 				panic(err)
 			}
 
-			if err := git.SwitchToMaster(); err != nil {
+			prUrl, err := cenv.CreatePR(branchName, title, message+".", config.GetPrLabels())
+
+			if err != nil {
 				panic(err)
 			}
-		}
+
+			supersedeMaintainerBranches(cenv, pkgbase, maintainer, branchName, prUrl)
+		})
+	}
+}
+
+func checkUpdatePolicy(ienv impenv.ImportEnv, tracker misc.PackageTracker) (bool, string) {
+	pconfig, err := pkg.LoadConfig(tracker.Pkgbase)
+
+	if err != nil {
+		panic(err)
 	}
+
+	allowed, reason, err := pconfig.CheckUpdatePolicy(tracker.UpstreamVersion, tracker.RepositoryVersion, func() (time.Time, error) {
+		return ienv.GetReleaseTime(tracker.Packages[0])
+	})
+
+	if err != nil {
+		panic(errors.New(fmt.Sprintf("invalid update policy for package %s: %s", tracker.Pkgbase, err)))
+	}
+
+	return allowed, reason
 }
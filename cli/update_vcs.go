@@ -4,59 +4,47 @@ import (
 	"flag"
 	"fmt"
 	"github.com/ryanpetris/aur-builder/cienv"
+	"github.com/ryanpetris/aur-builder/config"
 	"github.com/ryanpetris/aur-builder/git"
+	"github.com/ryanpetris/aur-builder/notify"
 	"github.com/ryanpetris/aur-builder/pkg"
 	"log/slog"
 	"os"
+	"strings"
+	"time"
 )
 
 func UpdateVcsMain(args []string) {
 	cmd := flag.NewFlagSet("update-vcs", flag.ExitOnError)
 
-	cmdPackage := cmd.String("package", "", "name of package to update")
-	cmdAll := cmd.Bool("all", false, "check all packages")
+	selector := addSelectionFlags(cmd, true)
+	cmdAll := cmd.Bool("all", false, "check packages without VCS information")
 
 	if err := cmd.Parse(args[1:]); err != nil {
 		panic(err)
 	}
 
-	if *cmdPackage != "" && *cmdAll {
-		slog.Error("--package and --all options are mutually-exclusive.")
-		os.Exit(1)
-	}
-
 	cenv := cienv.FindCiEnv()
-	allPackages, err := pkg.GetPackages()
+	movedTags := false
+	currentPkgbase := ""
 
-	if err != nil {
-		panic(err)
-	}
-
-	for _, pkgbase := range allPackages {
-		if *cmdPackage != "" && *cmdPackage != pkgbase {
-			continue
-		}
+	defer notifyOnPanic("update-vcs", &currentPkgbase)
 
+	for _, pkgbase := range selectPackages(cmd.Name(), selector) {
 		pconfig, err := pkg.LoadConfig(pkgbase)
 
 		if err != nil {
 			panic(err)
 		}
 
-		if *cmdPackage == "" {
-			if pconfig.Ignore {
-				continue
-			}
-
-			if pconfig.Vcs == nil && !*cmdAll {
-				continue
-			}
-		} else if *cmdPackage != pkgbase {
+		if pconfig.Vcs == nil && !*cmdAll && !selector.IsExplicit(pkgbase) {
 			continue
 		}
 
 		slog.Info(fmt.Sprintf("Checking package %s for VCS updates...", pkgbase))
+		currentPkgbase = pkgbase
 
+		oldVcs := pconfig.Vcs
 		updated, err := pconfig.GenVcsInfo(pkgbase)
 
 		if err != nil {
@@ -67,6 +55,26 @@ func UpdateVcsMain(args []string) {
 			continue
 		}
 
+		for _, moved := range pconfig.Vcs.MovedTags {
+			slog.Error(fmt.Sprintf("Package %s: %s", pkgbase, moved))
+			movedTags = true
+
+			notify.Notify(&notify.Event{
+				Type:    notify.EventFailure,
+				Pkgbase: pkgbase,
+				Message: fmt.Sprintf("Package %s: %s", pkgbase, moved),
+			})
+		}
+
+		if len(pconfig.Vcs.MovedTags) == 0 {
+			if allowed, reason, err := pconfig.CheckVcsPolicy(pkgbase, oldVcs); err != nil {
+				panic(err)
+			} else if !allowed {
+				slog.Info(fmt.Sprintf("Skipping VCS update for package %s due to policy: %s", pkgbase, reason))
+				continue
+			}
+		}
+
 		epoch, _, _, _, err := pkg.GetMergedVersionParts(pkgbase)
 
 		if err != nil {
@@ -84,44 +92,82 @@ func UpdateVcsMain(args []string) {
 
 		slog.Info(fmt.Sprintf("Updating package %s", pkgbase))
 
+		var before *pkg.PackageSnapshot
+
 		if cenv.IsCI() {
-			if err := git.CreateAndSwitchToPackageUpdateBranch(pkgbase, version); err != nil {
-				panic(err)
+			if before, err = pkg.TakePackageSnapshot(pkgbase, true); err != nil {
+				slog.Warn(fmt.Sprintf("Could not read current state of package %s: %s", pkgbase, err))
 			}
 		}
 
-		if err := pconfig.Write(pkgbase); err != nil {
-			panic(err)
-		}
+		modifyPackage(cenv, pkgbase, func() {
+			pconfig.Vcs.LastBump = time.Now().UTC().Format(time.RFC3339)
 
-		if err := pconfig.ClearMerge(pkgbase); err != nil {
-			panic(err)
-		}
-
-		if cenv.IsCI() {
-			if err := pconfig.Merge(pkgbase, true); err != nil {
+			if err := pconfig.Write(pkgbase); err != nil {
 				panic(err)
 			}
 
-			if err := git.AddAll(); err != nil {
+			if err := pconfig.ClearMerge(pkgbase); err != nil {
 				panic(err)
 			}
 
-			if err := git.Commit(fmt.Sprintf("Update %s at version %s", pkgbase, version)); err != nil {
-				panic(err)
-			}
+			if cenv.IsCI() {
+				if err := pconfig.Merge(pkgbase, true); err != nil {
+					panic(err)
+				}
 
-			if err := git.PushPackageBranch(pkgbase, version); err != nil {
-				panic(err)
-			}
+				message, err := config.RenderCommitMessage("Update", pkgbase, version)
 
-			if err := cenv.CreatePR(); err != nil {
-				panic(err)
-			}
+				if err != nil {
+					panic(err)
+				}
 
-			if err := git.SwitchToMaster(); err != nil {
-				panic(err)
+				title, err := config.RenderPrTitle("Update", pkgbase, version)
+
+				if err != nil {
+					panic(err)
+				}
+
+				body, err := pconfig.GeneratePrBody(pkgbase, before)
+
+				if err != nil {
+					panic(err)
+				}
+
+				if len(pconfig.Vcs.MovedTags) > 0 {
+					message = fmt.Sprintf("%s\n\nWARNING: upstream tags were moved:\n\n* %s", message, strings.Join(pconfig.Vcs.MovedTags, "\n* "))
+					body = fmt.Sprintf("%s\n### WARNING: upstream tags were moved\n\n- %s\n", body, strings.Join(pconfig.Vcs.MovedTags, "\n- "))
+				}
+
+				if err := git.CommitPackage(pkgbase, version, message); err != nil {
+					panic(err)
+				}
+
+				if err := git.PushPackageBranch(pkgbase, version); err != nil {
+					panic(err)
+				}
+
+				branchName, err := git.GetPackageBranchName(pkgbase, version)
+
+				if err != nil {
+					panic(err)
+				}
+
+				prUrl, err := cenv.CreatePR(branchName, title, body, config.GetPrLabels())
+
+				if err != nil {
+					panic(err)
+				}
+
+				notifyPullRequest(pkgbase, version, title, prUrl)
+				supersedePackageBranches(cenv, pkgbase, version, prUrl)
 			}
-		}
+		})
+	}
+
+	if movedTags {
+		slog.Error("Upstream tags were moved for one or more packages.")
+		notify.Flush()
+		os.Exit(1)
 	}
 }
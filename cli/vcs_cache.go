@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/ryanpetris/aur-builder/config"
+	"github.com/ryanpetris/aur-builder/git"
+	"github.com/ryanpetris/aur-builder/pkg"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+func VcsCacheMain(args []string) {
+	if config.GetVcsCachePath() == "" {
+		fmt.Println("vcsCachePath is not configured")
+		os.Exit(1)
+	}
+
+	if len(args) < 2 {
+		fmt.Println("invalid vcs-cache command")
+		os.Exit(1)
+	}
+
+	switch args[1] {
+	case "size":
+		vcsCacheSize()
+
+	case "prune":
+		vcsCachePrune(args[1:])
+
+	default:
+		fmt.Println("invalid vcs-cache command")
+		os.Exit(1)
+	}
+}
+
+func vcsCacheSize() {
+	mirrors, err := git.GetMirrors()
+
+	if err != nil {
+		panic(err)
+	}
+
+	var total int64
+
+	for _, mirror := range mirrors {
+		lastUsed := "never"
+
+		if !mirror.LastUsed.IsZero() {
+			lastUsed = mirror.LastUsed.Local().Format(time.DateTime)
+		}
+
+		fmt.Printf("%10s  %s  %s\n", formatSize(mirror.Size), lastUsed, mirror.Url)
+		total += mirror.Size
+	}
+
+	srcdestSize, err := git.GetDirectorySize(filepath.Join(config.GetVcsCachePath(), "srcdest"))
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		panic(err)
+	}
+
+	total += srcdestSize
+
+	fmt.Printf("%10s  other sources\n", formatSize(srcdestSize))
+	fmt.Printf("%10s  total (%d mirrors)\n", formatSize(total), len(mirrors))
+}
+
+func vcsCachePrune(args []string) {
+	cmd := flag.NewFlagSet("prune", flag.ExitOnError)
+
+	cmdMaxAge := cmd.Duration("max-age", 30*24*time.Hour, "remove mirrors not used within this duration")
+	cmdDryRun := cmd.Bool("dry-run", false, "only print what would be removed")
+
+	if err := cmd.Parse(args[1:]); err != nil {
+		panic(err)
+	}
+
+	mirrors, err := git.GetMirrors()
+
+	if err != nil {
+		panic(err)
+	}
+
+	cutoff := time.Now().Add(-*cmdMaxAge)
+	var freed int64
+
+	for _, mirror := range mirrors {
+		if mirror.LastUsed.After(cutoff) {
+			continue
+		}
+
+		slog.Info(fmt.Sprintf("Removing VCS mirror of %s (%s)", mirror.Url, formatSize(mirror.Size)))
+		freed += mirror.Size
+
+		if !*cmdDryRun {
+			if err := os.RemoveAll(mirror.Path); err != nil {
+				panic(err)
+			}
+		}
+	}
+
+	packages, err := pkg.GetPackages()
+
+	if err != nil {
+		panic(err)
+	}
+
+	srcdestPath := filepath.Join(config.GetVcsCachePath(), "srcdest")
+	entries, err := os.ReadDir(srcdestPath)
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		panic(err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || slices.Contains(packages, entry.Name()) {
+			continue
+		}
+
+		entryPath := filepath.Join(srcdestPath, entry.Name())
+		size, err := git.GetDirectorySize(entryPath)
+
+		if err != nil {
+			panic(err)
+		}
+
+		slog.Info(fmt.Sprintf("Removing cached sources for removed package %s (%s)", entry.Name(), formatSize(size)))
+		freed += size
+
+		if !*cmdDryRun {
+			if err := os.RemoveAll(entryPath); err != nil {
+				panic(err)
+			}
+		}
+	}
+
+	slog.Info(fmt.Sprintf("Freed %s", formatSize(freed)))
+}
+
+func formatSize(size int64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	value := float64(size)
+	unit := 0
+
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+
+	if unit == 0 {
+		return fmt.Sprintf("%d %s", size, units[unit])
+	}
+
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
@@ -0,0 +1,11 @@
+package config
+
+func (config *Config) GetCiEnv() string {
+	ciEnv := config.CiEnv
+
+	if ciEnv == "" {
+		ciEnv = "auto"
+	}
+
+	return ciEnv
+}
@@ -18,6 +18,43 @@ type Config struct {
 	AurPackagesPath string `yaml:"aurPackagesUrl,omitempty"`
 
 	ArchBaseGitUrl string `yaml:"archBaseGitUrl,omitempty"`
+
+	Repositories        []*RepositoryConfig `yaml:"repositories,omitempty"`
+	DefaultRepositories []string            `yaml:"defaultRepositories,omitempty"`
+
+	BaseBranch            string   `yaml:"baseBranch,omitempty"`
+	PushRemote            string   `yaml:"pushRemote,omitempty"`
+	TargetRemote          string   `yaml:"targetRemote,omitempty"`
+	BranchTemplate        string   `yaml:"branchTemplate,omitempty"`
+	CommitMessageTemplate string   `yaml:"commitMessageTemplate,omitempty"`
+	PrTitleTemplate       string   `yaml:"prTitleTemplate,omitempty"`
+	PrLabels              []string `yaml:"prLabels,omitempty"`
+	PrReviewers           []string `yaml:"prReviewers,omitempty"`
+
+	CommitterName        string `yaml:"committerName,omitempty"`
+	CommitterEmail       string `yaml:"committerEmail,omitempty"`
+	SigningFormat        string `yaml:"signingFormat,omitempty"`
+	SigningKeyPath       string `yaml:"signingKeyPath,omitempty"`
+	SigningKeyEnv        string `yaml:"signingKeyEnv,omitempty"`
+	SigningPassphraseEnv string `yaml:"signingPassphraseEnv,omitempty"`
+
+	Webhooks               []*WebhookConfig `yaml:"webhooks,omitempty"`
+	NotificationDigest     bool             `yaml:"notificationDigest,omitempty"`
+	NotificationRetries    *int             `yaml:"notificationRetries,omitempty"`
+	NotificationRetryDelay string           `yaml:"notificationRetryDelay,omitempty"`
+
+	CiEnv           string `yaml:"ciEnv,omitempty"`
+	LocalOutputPath string `yaml:"localOutputPath,omitempty"`
+
+	Sandbox      string `yaml:"sandbox,omitempty"`
+	VcsCachePath string `yaml:"vcsCachePath,omitempty"`
+
+	FlattenPkgbuild            bool              `yaml:"flattenPkgbuild,omitempty"`
+	ReplaceDependency          map[string]string `yaml:"replaceDependency,omitempty"`
+	ReplaceRenamedDependencies bool              `yaml:"replaceRenamedDependencies,omitempty"`
+
+	sources map[string]string
+	rootDir string
 }
 
 func (config *Config) Load(cfgpath string) error {
@@ -31,5 +68,31 @@ func (config *Config) Load(cfgpath string) error {
 		return err
 	}
 
-	return err
+	keys := map[string]yaml.Node{}
+
+	if err := yaml.Unmarshal(data, &keys); err != nil {
+		return err
+	}
+
+	for key := range keys {
+		config.SetSource(key, cfgpath)
+	}
+
+	return nil
+}
+
+func (config *Config) SetSource(key string, source string) {
+	if config.sources == nil {
+		config.sources = map[string]string{}
+	}
+
+	config.sources[key] = source
+}
+
+func (config *Config) GetSource(key string) string {
+	if source, ok := config.sources[key]; ok {
+		return source
+	}
+
+	return "default"
 }
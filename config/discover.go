@@ -0,0 +1,48 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+const discoveredConfigName = ".aur-builder.yaml"
+
+func FindConfigFile() (string, error) {
+	dir, err := os.Getwd()
+
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			cfgpath := filepath.Join(dir, discoveredConfigName)
+
+			if _, err := os.Stat(cfgpath); errors.Is(err, os.ErrNotExist) {
+				return "", nil
+			} else if err != nil {
+				return "", err
+			}
+
+			return cfgpath, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+
+		parent := filepath.Dir(dir)
+
+		if parent == dir {
+			return "", nil
+		}
+
+		dir = parent
+	}
+}
+
+// SetRootDir sets the directory relative paths are resolved against in place
+// of the current directory, such as the root of the repository a discovered
+// configuration file was found in.
+func (config *Config) SetRootDir(dir string) {
+	config.rootDir = dir
+}
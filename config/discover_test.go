@@ -0,0 +1,84 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiscoveredConfigPaths(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subdir := filepath.Join(root, "packages", "foo")
+
+	for _, dir := range []string{filepath.Join(root, ".git"), subdir} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data := "basePath: pkgs\nvcsCachePath: cache\nlocalOutputPath: /tmp/updates\n"
+
+	if err := os.WriteFile(filepath.Join(root, discoveredConfigName), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Chdir(subdir); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		_ = os.Chdir(wd)
+	})
+
+	cfgpath, err := FindConfigFile()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfgpath != filepath.Join(root, discoveredConfigName) {
+		t.Fatalf("expected to discover the configuration at the repository root, got %q", cfgpath)
+	}
+
+	cfg := &Config{}
+
+	if err := cfg.Load(cfgpath); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.SetRootDir(filepath.Dir(cfgpath))
+
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{"basePath", cfg.GetBasePath(), filepath.Join(root, "pkgs")},
+		{"package", cfg.GetMergedPath("foo"), filepath.Join(root, "pkgs", "foo", "merged")},
+		{"vcsCachePath", cfg.GetVcsCachePath(), filepath.Join(root, "cache")},
+		{"localOutputPath", cfg.GetLocalOutputPath(), "/tmp/updates"},
+	}
+
+	for _, test := range tests {
+		if test.path != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.name, test.expected, test.path)
+		}
+	}
+
+	cfg.SetRootDir("")
+
+	if basePath := cfg.GetBasePath(); basePath != filepath.Join(subdir, "pkgs") {
+		t.Errorf("expected paths to resolve against the current directory without a root, got %q", basePath)
+	}
+}
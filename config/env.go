@@ -0,0 +1,79 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+const envPrefix = "AUR_BUILDER_"
+
+func (config *Config) LoadEnv() error {
+	value := reflect.ValueOf(config).Elem()
+
+	for index := 0; index < value.NumField(); index++ {
+		key := getYamlKey(value.Type().Field(index))
+
+		if key == "" {
+			continue
+		}
+
+		envName := GetEnvName(key)
+		envValue, ok := os.LookupEnv(envName)
+
+		if !ok {
+			continue
+		}
+
+		field := value.Field(index)
+
+		if field.Kind() == reflect.String {
+			field.SetString(envValue)
+		} else {
+			parsed := reflect.New(field.Type())
+
+			if err := yaml.Unmarshal([]byte(envValue), parsed.Interface()); err != nil {
+				return errors.New(fmt.Sprintf("invalid value for %s: %s", envName, err))
+			}
+
+			field.Set(parsed.Elem())
+		}
+
+		config.SetSource(key, fmt.Sprintf("$%s", envName))
+	}
+
+	return nil
+}
+
+func GetEnvName(key string) string {
+	name := strings.Builder{}
+	name.WriteString(envPrefix)
+
+	for index, char := range key {
+		if unicode.IsUpper(char) && index > 0 {
+			name.WriteRune('_')
+		}
+
+		name.WriteRune(unicode.ToUpper(char))
+	}
+
+	return name.String()
+}
+
+func getYamlKey(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+
+	key := strings.Split(field.Tag.Get("yaml"), ",")[0]
+
+	if key == "-" {
+		return ""
+	}
+
+	return key
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"text/template"
+)
+
+type TemplateData struct {
+	Action  string
+	Pkgbase string
+	Version string
+}
+
+func (config *Config) GetBaseBranch() string {
+	baseBranch := config.BaseBranch
+
+	if baseBranch == "" {
+		baseBranch = "master"
+	}
+
+	return baseBranch
+}
+
+func (config *Config) GetPushRemote() string {
+	pushRemote := config.PushRemote
+
+	if pushRemote == "" {
+		pushRemote = "origin"
+	}
+
+	return pushRemote
+}
+
+func (config *Config) GetTargetRemote() string {
+	targetRemote := config.TargetRemote
+
+	if targetRemote == "" {
+		targetRemote = config.GetPushRemote()
+	}
+
+	return targetRemote
+}
+
+func (config *Config) GetBranchTemplate() string {
+	branchTemplate := config.BranchTemplate
+
+	if branchTemplate == "" {
+		branchTemplate = "packages/{{ .Pkgbase }}/{{ .Version }}"
+	}
+
+	return branchTemplate
+}
+
+func (config *Config) GetCommitMessageTemplate() string {
+	commitMessageTemplate := config.CommitMessageTemplate
+
+	if commitMessageTemplate == "" {
+		commitMessageTemplate = "{{ .Action }} {{ .Pkgbase }} at version {{ .Version }}"
+	}
+
+	return commitMessageTemplate
+}
+
+func (config *Config) GetPrTitleTemplate() string {
+	prTitleTemplate := config.PrTitleTemplate
+
+	if prTitleTemplate == "" {
+		prTitleTemplate = config.GetCommitMessageTemplate()
+	}
+
+	return prTitleTemplate
+}
+
+func (config *Config) GetSigningFormat() string {
+	signingFormat := config.SigningFormat
+
+	if signingFormat == "" {
+		signingFormat = "openpgp"
+	}
+
+	return signingFormat
+}
+
+func (config *Config) GetSigningKey() ([]byte, error) {
+	if config.SigningKeyEnv != "" {
+		key := os.Getenv(config.SigningKeyEnv)
+
+		if key == "" {
+			return nil, errors.New(fmt.Sprintf("signing key environment variable %s is not set", config.SigningKeyEnv))
+		}
+
+		return []byte(key), nil
+	}
+
+	if config.SigningKeyPath != "" {
+		return os.ReadFile(config.resolvePath(config.SigningKeyPath))
+	}
+
+	return nil, nil
+}
+
+func (config *Config) GetSigningPassphrase() []byte {
+	if config.SigningPassphraseEnv == "" {
+		return nil
+	}
+
+	return []byte(os.Getenv(config.SigningPassphraseEnv))
+}
+
+func (config *Config) RenderBranchName(pkgbase string, version string) (string, error) {
+	return renderTemplate(config.GetBranchTemplate(), TemplateData{Pkgbase: pkgbase, Version: version})
+}
+
+func (config *Config) RenderCommitMessage(action string, pkgbase string, version string) (string, error) {
+	return renderTemplate(config.GetCommitMessageTemplate(), TemplateData{Action: action, Pkgbase: pkgbase, Version: version})
+}
+
+func (config *Config) RenderPrTitle(action string, pkgbase string, version string) (string, error) {
+	return renderTemplate(config.GetPrTitleTemplate(), TemplateData{Action: action, Pkgbase: pkgbase, Version: version})
+}
+
+func renderTemplate(text string, data TemplateData) (string, error) {
+	tmpl, err := template.New("").Option("missingkey=error").Parse(text)
+
+	if err != nil {
+		return "", err
+	}
+
+	buf := bytes.Buffer{}
+
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"sync"
+	"time"
 )
 
 var config *Config
@@ -81,6 +82,12 @@ func GetAurPackageGitUrl(pkgbase string) string {
 	return config.GetAurPackageGitUrl(pkgbase)
 }
 
+func GetAurPackagePageUrl(pkgbase string) string {
+	config := GetGlobalConfig()
+
+	return config.GetAurPackagePageUrl(pkgbase)
+}
+
 func GetArchBaseGitUrl() string {
 	config := GetGlobalConfig()
 
@@ -92,3 +99,177 @@ func GetArchPackageGitUrl(pkgbase string) string {
 
 	return config.GetArchPackageGitUrl(pkgbase)
 }
+
+func GetArchPackagePageUrl(pkgbase string) string {
+	config := GetGlobalConfig()
+
+	return config.GetArchPackagePageUrl(pkgbase)
+}
+
+func GetSandbox() string {
+	config := GetGlobalConfig()
+
+	return config.GetSandbox()
+}
+
+func GetFlattenPkgbuild() bool {
+	config := GetGlobalConfig()
+
+	return config.GetFlattenPkgbuild()
+}
+
+func GetReplaceDependency() map[string]string {
+	config := GetGlobalConfig()
+
+	return config.GetReplaceDependency()
+}
+
+func GetReplaceRenamedDependencies() bool {
+	config := GetGlobalConfig()
+
+	return config.GetReplaceRenamedDependencies()
+}
+
+func GetVcsCachePath() string {
+	config := GetGlobalConfig()
+
+	return config.GetVcsCachePath()
+}
+
+func GetWebhooks() []*WebhookConfig {
+	config := GetGlobalConfig()
+
+	return config.GetWebhooks()
+}
+
+func GetNotificationDigest() bool {
+	config := GetGlobalConfig()
+
+	return config.GetNotificationDigest()
+}
+
+func GetNotificationRetries() int {
+	config := GetGlobalConfig()
+
+	return config.GetNotificationRetries()
+}
+
+func GetNotificationRetryDelay() (time.Duration, error) {
+	config := GetGlobalConfig()
+
+	return config.GetNotificationRetryDelay()
+}
+
+func GetLocalOutputPath() string {
+	config := GetGlobalConfig()
+
+	return config.GetLocalOutputPath()
+}
+
+func GetCiEnv() string {
+	config := GetGlobalConfig()
+
+	return config.GetCiEnv()
+}
+
+func GetVcsMirrorsPath() string {
+	config := GetGlobalConfig()
+
+	return config.GetVcsMirrorsPath()
+}
+
+func GetVcsSrcdestPath(pkgbase string) string {
+	config := GetGlobalConfig()
+
+	return config.GetVcsSrcdestPath(pkgbase)
+}
+
+func GetRepositories() []*RepositoryConfig {
+	config := GetGlobalConfig()
+
+	return config.GetRepositories()
+}
+
+func GetRepository(name string) *RepositoryConfig {
+	config := GetGlobalConfig()
+
+	return config.GetRepository(name)
+}
+
+func GetDefaultRepositories() []string {
+	config := GetGlobalConfig()
+
+	return config.GetDefaultRepositories()
+}
+
+func GetBaseBranch() string {
+	config := GetGlobalConfig()
+
+	return config.GetBaseBranch()
+}
+
+func GetPushRemote() string {
+	config := GetGlobalConfig()
+
+	return config.GetPushRemote()
+}
+
+func GetTargetRemote() string {
+	config := GetGlobalConfig()
+
+	return config.GetTargetRemote()
+}
+
+func GetPrLabels() []string {
+	config := GetGlobalConfig()
+
+	return config.PrLabels
+}
+
+func GetPrReviewers() []string {
+	config := GetGlobalConfig()
+
+	return config.PrReviewers
+}
+
+func GetCommitter() (string, string) {
+	config := GetGlobalConfig()
+
+	return config.CommitterName, config.CommitterEmail
+}
+
+func GetSigningFormat() string {
+	config := GetGlobalConfig()
+
+	return config.GetSigningFormat()
+}
+
+func GetSigningKey() ([]byte, error) {
+	config := GetGlobalConfig()
+
+	return config.GetSigningKey()
+}
+
+func GetSigningPassphrase() []byte {
+	config := GetGlobalConfig()
+
+	return config.GetSigningPassphrase()
+}
+
+func RenderBranchName(pkgbase string, version string) (string, error) {
+	config := GetGlobalConfig()
+
+	return config.RenderBranchName(pkgbase, version)
+}
+
+func RenderCommitMessage(action string, pkgbase string, version string) (string, error) {
+	config := GetGlobalConfig()
+
+	return config.RenderCommitMessage(action, pkgbase, version)
+}
+
+func RenderPrTitle(action string, pkgbase string, version string) (string, error) {
+	config := GetGlobalConfig()
+
+	return config.RenderPrTitle(action, pkgbase, version)
+}
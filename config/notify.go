@@ -0,0 +1,73 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+)
+
+type WebhookConfig struct {
+	Url     string            `yaml:"url,omitempty"`
+	UrlEnv  string            `yaml:"urlEnv,omitempty"`
+	Format  string            `yaml:"format,omitempty"`
+	Events  []string          `yaml:"events,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+}
+
+func (config *Config) GetWebhooks() []*WebhookConfig {
+	return config.Webhooks
+}
+
+func (config *Config) GetNotificationDigest() bool {
+	return config.NotificationDigest
+}
+
+func (config *Config) GetNotificationRetries() int {
+	if config.NotificationRetries == nil {
+		return 3
+	}
+
+	return *config.NotificationRetries
+}
+
+func (config *Config) GetNotificationRetryDelay() (time.Duration, error) {
+	if config.NotificationRetryDelay == "" {
+		return 5 * time.Second, nil
+	}
+
+	return time.ParseDuration(config.NotificationRetryDelay)
+}
+
+func (webhook *WebhookConfig) GetUrl() (string, error) {
+	if webhook.UrlEnv != "" {
+		url := os.Getenv(webhook.UrlEnv)
+
+		if url == "" {
+			return "", errors.New(fmt.Sprintf("webhook url environment variable %s is not set", webhook.UrlEnv))
+		}
+
+		return url, nil
+	}
+
+	if webhook.Url == "" {
+		return "", errors.New("webhook url is required")
+	}
+
+	return webhook.Url, nil
+}
+
+func (webhook *WebhookConfig) GetFormat() string {
+	format := webhook.Format
+
+	if format == "" {
+		format = "generic"
+	}
+
+	return format
+}
+
+func (webhook *WebhookConfig) WantsEvent(eventType string) bool {
+	return len(webhook.Events) == 0 || slices.Contains(webhook.Events, eventType)
+}
@@ -0,0 +1,19 @@
+package config
+
+func (config *Config) GetFlattenPkgbuild() bool {
+	return config.FlattenPkgbuild
+}
+
+func (config *Config) GetReplaceDependency() map[string]string {
+	result := map[string]string{}
+
+	for from, to := range config.ReplaceDependency {
+		result[from] = to
+	}
+
+	return result
+}
+
+func (config *Config) GetReplaceRenamedDependencies() bool {
+	return config.ReplaceRenamedDependencies
+}
@@ -12,9 +12,7 @@ func (config *Config) GetBasePath() string {
 		basePath = "packages"
 	}
 
-	result, _ := filepath.Abs(basePath)
-
-	return result
+	return config.resolvePath(basePath)
 }
 
 func (config *Config) GetPackagePath(pkgbase string) string {
@@ -108,6 +106,12 @@ func (config *Config) GetAurPackageGitUrl(pkgbase string) string {
 	return fmt.Sprintf("%s/%s.git", baseUrl, pkgbase)
 }
 
+func (config *Config) GetAurPackagePageUrl(pkgbase string) string {
+	baseUrl := config.GetAurBaseUrl()
+
+	return fmt.Sprintf("%s/pkgbase/%s", baseUrl, pkgbase)
+}
+
 func (config *Config) GetArchBaseGitUrl() string {
 	baseUrl := config.ArchBaseGitUrl
 
@@ -123,3 +127,45 @@ func (config *Config) GetArchPackageGitUrl(pkgbase string) string {
 
 	return fmt.Sprintf("%s/packaging/packages/%s.git", baseUrl, pkgbase)
 }
+
+func (config *Config) GetArchPackagePageUrl(pkgbase string) string {
+	baseUrl := config.GetArchBaseGitUrl()
+
+	return fmt.Sprintf("%s/packaging/packages/%s", baseUrl, pkgbase)
+}
+
+func (config *Config) GetVcsCachePath() string {
+	if config.VcsCachePath == "" {
+		return ""
+	}
+
+	return config.resolvePath(config.VcsCachePath)
+}
+
+func (config *Config) GetLocalOutputPath() string {
+	outputPath := config.LocalOutputPath
+
+	if outputPath == "" {
+		outputPath = "updates"
+	}
+
+	return config.resolvePath(outputPath)
+}
+
+func (config *Config) GetVcsMirrorsPath() string {
+	return filepath.Join(config.GetVcsCachePath(), "mirrors")
+}
+
+func (config *Config) GetVcsSrcdestPath(pkgbase string) string {
+	return filepath.Join(config.GetVcsCachePath(), "srcdest", pkgbase)
+}
+
+func (config *Config) resolvePath(value string) string {
+	if config.rootDir != "" && !filepath.IsAbs(value) {
+		value = filepath.Join(config.rootDir, value)
+	}
+
+	result, _ := filepath.Abs(value)
+
+	return result
+}
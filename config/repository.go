@@ -0,0 +1,42 @@
+package config
+
+type RepositoryConfig struct {
+	Name string `yaml:"name,omitempty"`
+	Db   string `yaml:"db,omitempty"`
+}
+
+func (config *Config) GetRepositories() []*RepositoryConfig {
+	return config.Repositories
+}
+
+func (config *Config) GetRepository(name string) *RepositoryConfig {
+	for _, repository := range config.Repositories {
+		if repository.Name == name {
+			return repository
+		}
+	}
+
+	return nil
+}
+
+func (config *Config) GetDefaultRepositories() []string {
+	if config.DefaultRepositories != nil {
+		return config.DefaultRepositories
+	}
+
+	var result []string
+
+	for _, repository := range config.Repositories {
+		result = append(result, repository.Name)
+	}
+
+	return result
+}
+
+func (repository *RepositoryConfig) GetDb() string {
+	if repository.Db == "" {
+		return repository.Name
+	}
+
+	return repository.Db
+}
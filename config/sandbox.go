@@ -0,0 +1,11 @@
+package config
+
+func (config *Config) GetSandbox() string {
+	sandbox := config.Sandbox
+
+	if sandbox == "" {
+		sandbox = "auto"
+	}
+
+	return sandbox
+}
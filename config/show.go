@@ -0,0 +1,100 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+type ConfigValue struct {
+	Key    string
+	Value  any
+	Source string
+}
+
+func (config *Config) GetEffectiveValues() ([]*ConfigValue, error) {
+	retryDelay, err := config.GetNotificationRetryDelay()
+
+	if err != nil {
+		return nil, err
+	}
+
+	effective := map[string]any{
+		"basePath":               config.GetBasePath(),
+		"configPath":             config.getPackageRelPath(config.GetConfigPath),
+		"localPath":              config.getPackageRelPath(config.GetLocalPath),
+		"mergedPath":             config.getPackageRelPath(config.GetMergedPath),
+		"scriptsPath":            config.getPackageRelPath(config.GetScriptsPath),
+		"scriptOverridePath":     config.getPackageRelPath(config.GetScriptOverridePath),
+		"upstreamPath":           config.getPackageRelPath(config.GetUpstreamPath),
+		"aurBaseUrl":             config.GetAurBaseUrl(),
+		"aurPackagesUrl":         strings.TrimPrefix(config.GetAurPackagesUrl(), config.GetAurBaseUrl()+"/"),
+		"archBaseGitUrl":         config.GetArchBaseGitUrl(),
+		"defaultRepositories":    config.GetDefaultRepositories(),
+		"baseBranch":             config.GetBaseBranch(),
+		"pushRemote":             config.GetPushRemote(),
+		"targetRemote":           config.GetTargetRemote(),
+		"branchTemplate":         config.GetBranchTemplate(),
+		"commitMessageTemplate":  config.GetCommitMessageTemplate(),
+		"prTitleTemplate":        config.GetPrTitleTemplate(),
+		"signingFormat":          config.GetSigningFormat(),
+		"webhooks":               config.getRedactedWebhooks(),
+		"notificationRetries":    config.GetNotificationRetries(),
+		"notificationRetryDelay": retryDelay.String(),
+		"ciEnv":                  config.GetCiEnv(),
+		"localOutputPath":        config.GetLocalOutputPath(),
+		"sandbox":                config.GetSandbox(),
+		"vcsCachePath":           config.GetVcsCachePath(),
+	}
+
+	var result []*ConfigValue
+	value := reflect.ValueOf(config).Elem()
+
+	for index := 0; index < value.NumField(); index++ {
+		key := getYamlKey(value.Type().Field(index))
+
+		if key == "" {
+			continue
+		}
+
+		item := &ConfigValue{
+			Key:    key,
+			Value:  value.Field(index).Interface(),
+			Source: config.GetSource(key),
+		}
+
+		if effectiveValue, ok := effective[key]; ok {
+			item.Value = effectiveValue
+		}
+
+		result = append(result, item)
+	}
+
+	return result, nil
+}
+
+func (config *Config) getPackageRelPath(getPath func(pkgbase string) string) string {
+	result, _ := filepath.Rel(config.GetBasePath(), getPath(""))
+
+	return result
+}
+
+func (config *Config) getRedactedWebhooks() []*WebhookConfig {
+	var result []*WebhookConfig
+
+	for _, webhook := range config.Webhooks {
+		redacted := *webhook
+
+		if redacted.Headers != nil {
+			redacted.Headers = map[string]string{}
+
+			for key := range webhook.Headers {
+				redacted.Headers[key] = "<redacted>"
+			}
+		}
+
+		result = append(result, &redacted)
+	}
+
+	return result
+}
@@ -0,0 +1,28 @@
+package forge
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+type ApiError struct {
+	Method     string
+	Url        string
+	StatusCode int
+	Message    string
+}
+
+func (err *ApiError) Error() string {
+	if err.Message == "" {
+		return fmt.Sprintf("%s %s failed with status %d", err.Method, err.Url, err.StatusCode)
+	}
+
+	return fmt.Sprintf("%s %s failed with status %d: %s", err.Method, err.Url, err.StatusCode, err.Message)
+}
+
+func IsNotFound(err error) bool {
+	var apiErr *ApiError
+
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,38 @@
+package forge
+
+const (
+	StateOpen   = "open"
+	StateClosed = "closed"
+	StateMerged = "merged"
+)
+
+type PullRequest struct {
+	Number int
+	State  string
+	Url    string
+}
+
+type PullRequestOptions struct {
+	HeadRepository string
+	HeadBranch     string
+	BaseBranch     string
+	Title          string
+	Body           string
+}
+
+type Client interface {
+	CreatePullRequest(options *PullRequestOptions) (*PullRequest, error)
+	FindPullRequest(headRepository string, headBranch string) (*PullRequest, error)
+	ClosePullRequest(number int) error
+	AddComment(number int, body string) error
+	AddLabels(number int, labels []string) error
+	AddReviewers(number int, reviewers []string) error
+}
+
+func preferPullRequest(current *PullRequest, candidate *PullRequest) *PullRequest {
+	if current == nil || (current.State != StateOpen && candidate.State == StateOpen) {
+		return candidate
+	}
+
+	return current
+}
@@ -0,0 +1,251 @@
+package forge
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type GiteaClient struct {
+	api        *apiClient
+	repository string
+	pulls      map[string][]*giteaPullRequest
+}
+
+type giteaPullRequest struct {
+	Number  int    `json:"number"`
+	State   string `json:"state"`
+	Merged  bool   `json:"merged"`
+	HtmlUrl string `json:"html_url"`
+	Head    struct {
+		Ref  string `json:"ref"`
+		Repo struct {
+			FullName string `json:"full_name"`
+		} `json:"repo"`
+	} `json:"head"`
+}
+
+type giteaLabel struct {
+	Id   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+func NewGiteaClient(apiUrl string, repository string, username string, password string) *GiteaClient {
+	api := newApiClient(apiUrl)
+	api.username = username
+	api.password = password
+
+	return &GiteaClient{
+		api:        api,
+		repository: repository,
+	}
+}
+
+func (client *GiteaClient) CreatePullRequest(options *PullRequestOptions) (*PullRequest, error) {
+	data := map[string]any{
+		"head":  getHead(client.repository, options),
+		"base":  options.BaseBranch,
+		"title": options.Title,
+		"body":  options.Body,
+	}
+
+	pr := giteaPullRequest{}
+
+	if err := client.api.request("POST", fmt.Sprintf("repos/%s/pulls", client.repository), data, &pr); err != nil {
+		return nil, err
+	}
+
+	client.cachePullRequest(&pr)
+
+	return pr.toPullRequest(), nil
+}
+
+func (client *GiteaClient) FindPullRequest(headRepository string, headBranch string) (*PullRequest, error) {
+	for _, state := range []string{"open", "closed"} {
+		pr, err := client.findPullRequest(state, headRepository, headBranch)
+
+		if err != nil || pr != nil {
+			return pr, err
+		}
+	}
+
+	return nil, nil
+}
+
+// Gitea can't filter pull requests by head branch, so each state is listed
+// once, most recently updated first, and reused for later lookups.
+func (client *GiteaClient) findPullRequest(state string, headRepository string, headBranch string) (*PullRequest, error) {
+	if headRepository == "" {
+		headRepository = client.repository
+	}
+
+	prs, err := client.listPullRequests(state)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pr := range prs {
+		if pr.Head.Ref == headBranch && strings.EqualFold(pr.Head.Repo.FullName, headRepository) {
+			return pr.toPullRequest(), nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (client *GiteaClient) listPullRequests(state string) ([]*giteaPullRequest, error) {
+	if prs, ok := client.pulls[state]; ok {
+		return prs, nil
+	}
+
+	prs := []*giteaPullRequest{}
+
+	for page := 1; ; page++ {
+		var pagePrs []*giteaPullRequest
+
+		if err := client.api.request("GET", fmt.Sprintf("repos/%s/pulls?state=%s&sort=recentupdate&limit=50&page=%d", client.repository, state, page), nil, &pagePrs); err != nil {
+			return nil, err
+		}
+
+		if len(pagePrs) == 0 {
+			break
+		}
+
+		for _, pr := range pagePrs {
+			if !slices.ContainsFunc(prs, func(cached *giteaPullRequest) bool { return cached.Number == pr.Number }) {
+				prs = append(prs, pr)
+			}
+		}
+	}
+
+	if client.pulls == nil {
+		client.pulls = map[string][]*giteaPullRequest{}
+	}
+
+	client.pulls[state] = prs
+
+	return prs, nil
+}
+
+// Keeps the cached listings in step with pull requests created or closed
+// through this client.
+func (client *GiteaClient) cachePullRequest(pr *giteaPullRequest) {
+	for state, prs := range client.pulls {
+		prs = slices.DeleteFunc(prs, func(cached *giteaPullRequest) bool { return cached.Number == pr.Number })
+
+		if state == pr.State {
+			prs = append([]*giteaPullRequest{pr}, prs...)
+		}
+
+		client.pulls[state] = prs
+	}
+}
+
+func (client *GiteaClient) ClosePullRequest(number int) error {
+	data := map[string]any{
+		"state": "closed",
+	}
+
+	pr := giteaPullRequest{}
+
+	if err := client.api.request("PATCH", fmt.Sprintf("repos/%s/pulls/%d", client.repository, number), data, &pr); err != nil {
+		return err
+	}
+
+	if pr.Number != number {
+		client.pulls = nil
+		return nil
+	}
+
+	client.cachePullRequest(&pr)
+
+	return nil
+}
+
+func (client *GiteaClient) AddComment(number int, body string) error {
+	data := map[string]any{
+		"body": body,
+	}
+
+	return client.api.request("POST", fmt.Sprintf("repos/%s/issues/%d/comments", client.repository, number), data, nil)
+}
+
+func (client *GiteaClient) AddLabels(number int, labels []string) error {
+	if len(labels) == 0 {
+		return nil
+	}
+
+	labelIds := map[string]int64{}
+
+	for page := 1; ; page++ {
+		var repoLabels []*giteaLabel
+
+		if err := client.api.request("GET", fmt.Sprintf("repos/%s/labels?limit=50&page=%d", client.repository, page), nil, &repoLabels); err != nil {
+			return err
+		}
+
+		if len(repoLabels) == 0 {
+			break
+		}
+
+		for _, label := range repoLabels {
+			labelIds[label.Name] = label.Id
+		}
+	}
+
+	var ids []int64
+
+	for _, label := range labels {
+		id, ok := labelIds[label]
+
+		if !ok {
+			return errors.New(fmt.Sprintf("label %s does not exist in repository %s", label, client.repository))
+		}
+
+		ids = append(ids, id)
+	}
+
+	data := map[string]any{
+		"labels": ids,
+	}
+
+	return client.api.request("POST", fmt.Sprintf("repos/%s/issues/%d/labels", client.repository, number), data, nil)
+}
+
+func (client *GiteaClient) AddReviewers(number int, reviewers []string) error {
+	if len(reviewers) == 0 {
+		return nil
+	}
+
+	data := map[string]any{
+		"reviewers": reviewers,
+	}
+
+	return client.api.request("POST", fmt.Sprintf("repos/%s/pulls/%d/requested_reviewers", client.repository, number), data, nil)
+}
+
+func (pr *giteaPullRequest) toPullRequest() *PullRequest {
+	state := StateClosed
+
+	if pr.Merged {
+		state = StateMerged
+	} else if pr.State == "open" {
+		state = StateOpen
+	}
+
+	return &PullRequest{
+		Number: pr.Number,
+		State:  state,
+		Url:    pr.HtmlUrl,
+	}
+}
+
+func getHead(repository string, options *PullRequestOptions) string {
+	if options.HeadRepository == "" || strings.EqualFold(options.HeadRepository, repository) {
+		return options.HeadBranch
+	}
+
+	return fmt.Sprintf("%s:%s", strings.SplitN(options.HeadRepository, "/", 2)[0], options.HeadBranch)
+}
@@ -0,0 +1,129 @@
+package forge
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGiteaFindPullRequest(t *testing.T) {
+	pulls := map[string][][]map[string]any{
+		"open": {
+			{giteaTestPull(1, "open", "other", "owner/repo")},
+			{giteaTestPull(2, "open", "update/foo", "owner/repo")},
+			{giteaTestPull(3, "open", "update/foo", "owner/repo")},
+		},
+		"closed": {
+			{giteaTestPull(4, "closed", "update/foo", "fork/repo"), giteaTestPull(5, "closed", "update/bar", "owner/repo")},
+			{giteaTestPull(6, "closed", "update/bar", "owner/repo"), giteaTestPull(5, "closed", "update/bar", "owner/repo")},
+		},
+	}
+
+	var requested []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/repos/owner/repo/pulls":
+			requested = append(requested, "create")
+			_ = json.NewEncoder(w).Encode(giteaTestPull(7, "open", "update/new", "owner/repo"))
+			return
+		case r.Method == "PATCH" && r.URL.Path == "/repos/owner/repo/pulls/2":
+			requested = append(requested, "close")
+			_ = json.NewEncoder(w).Encode(giteaTestPull(2, "closed", "update/foo", "owner/repo"))
+			return
+		}
+
+		requested = append(requested, fmt.Sprintf("%s:%s", query.Get("state"), query.Get("page")))
+
+		if r.Method != "GET" || r.URL.Path != "/repos/owner/repo/pulls" || query.Get("sort") != "recentupdate" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+
+		var page int
+		fmt.Sscan(query.Get("page"), &page)
+		result := []map[string]any{}
+
+		if pages := pulls[query.Get("state")]; page <= len(pages) {
+			result = pages[page-1]
+		}
+
+		_ = json.NewEncoder(w).Encode(result)
+	}))
+
+	defer server.Close()
+
+	client := NewGiteaClient(server.URL, "owner/repo", "", "")
+
+	tests := []struct {
+		name      string
+		action    func() error
+		head      string
+		branch    string
+		number    int
+		state     string
+		requested []string
+	}{
+		{name: "open lists open once", head: "owner/repo", branch: "update/foo", number: 2, state: StateOpen, requested: []string{"open:1", "open:2", "open:3", "open:4"}},
+		{name: "closed fallback lists closed once", head: "owner/repo", branch: "update/bar", number: 5, state: StateClosed, requested: []string{"closed:1", "closed:2", "closed:3"}},
+		{name: "fork", head: "fork/repo", branch: "update/foo", number: 4, state: StateClosed},
+		{name: "not found", head: "owner/repo", branch: "update/baz"},
+		{name: "created", action: func() error {
+			_, err := client.CreatePullRequest(&PullRequestOptions{HeadBranch: "update/new", BaseBranch: "main"})
+			return err
+		}, head: "owner/repo", branch: "update/new", number: 7, state: StateOpen, requested: []string{"create"}},
+		{name: "closed", action: func() error {
+			return client.ClosePullRequest(2)
+		}, head: "owner/repo", branch: "update/foo", number: 3, state: StateOpen, requested: []string{"close"}},
+		{name: "closed listed", head: "fork/repo", branch: "update/foo", number: 4, state: StateClosed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			requested = nil
+
+			if test.action != nil {
+				if err := test.action(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			pr, err := client.FindPullRequest(test.head, test.branch)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if test.number == 0 {
+				if pr != nil {
+					t.Errorf("expected no pull request, got %+v", pr)
+				}
+			} else if pr == nil || pr.Number != test.number || pr.State != test.state {
+				t.Errorf("expected pull request %d (%s), got %+v", test.number, test.state, pr)
+			}
+
+			if fmt.Sprint(requested) != fmt.Sprint(test.requested) {
+				t.Errorf("expected requests %v, got %v", test.requested, requested)
+			}
+		})
+	}
+
+	if closed := client.pulls["closed"]; len(closed) != 4 || closed[0].Number != 2 {
+		t.Errorf("expected the closed pull request to be cached first, got %d cached", len(closed))
+	}
+}
+
+func giteaTestPull(number int, state string, ref string, repository string) map[string]any {
+	return map[string]any{
+		"number":   number,
+		"state":    state,
+		"html_url": fmt.Sprintf("https://example.com/pulls/%d", number),
+		"head": map[string]any{
+			"ref":  ref,
+			"repo": map[string]any{"full_name": repository},
+		},
+	}
+}
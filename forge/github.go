@@ -0,0 +1,139 @@
+package forge
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+type GithubClient struct {
+	api        *apiClient
+	repository string
+}
+
+type githubPullRequest struct {
+	Number   int     `json:"number"`
+	State    string  `json:"state"`
+	MergedAt *string `json:"merged_at"`
+	HtmlUrl  string  `json:"html_url"`
+}
+
+func NewGithubClient(apiUrl string, repository string, token string) *GithubClient {
+	if apiUrl == "" {
+		apiUrl = "https://api.github.com"
+	}
+
+	api := newApiClient(apiUrl)
+	api.headers["Accept"] = "application/vnd.github+json"
+	api.headers["X-GitHub-Api-Version"] = "2022-11-28"
+
+	if token != "" {
+		api.headers["Authorization"] = fmt.Sprintf("Bearer %s", token)
+	}
+
+	return &GithubClient{
+		api:        api,
+		repository: repository,
+	}
+}
+
+func (client *GithubClient) CreatePullRequest(options *PullRequestOptions) (*PullRequest, error) {
+	data := map[string]any{
+		"head":  getHead(client.repository, options),
+		"base":  options.BaseBranch,
+		"title": options.Title,
+		"body":  options.Body,
+	}
+
+	pr := githubPullRequest{}
+
+	if err := client.api.request("POST", fmt.Sprintf("repos/%s/pulls", client.repository), data, &pr); err != nil {
+		return nil, err
+	}
+
+	return pr.toPullRequest(), nil
+}
+
+func (client *GithubClient) FindPullRequest(headRepository string, headBranch string) (*PullRequest, error) {
+	if headRepository == "" {
+		headRepository = client.repository
+	}
+
+	head := fmt.Sprintf("%s:%s", strings.SplitN(headRepository, "/", 2)[0], headBranch)
+
+	var result *PullRequest
+
+	for page := 1; ; page++ {
+		var prs []*githubPullRequest
+
+		if err := client.api.request("GET", fmt.Sprintf("repos/%s/pulls?state=all&head=%s&per_page=100&page=%d", client.repository, url.QueryEscape(head), page), nil, &prs); err != nil {
+			return nil, err
+		}
+
+		if len(prs) == 0 {
+			break
+		}
+
+		for _, pr := range prs {
+			result = preferPullRequest(result, pr.toPullRequest())
+		}
+	}
+
+	return result, nil
+}
+
+func (client *GithubClient) ClosePullRequest(number int) error {
+	data := map[string]any{
+		"state": "closed",
+	}
+
+	return client.api.request("PATCH", fmt.Sprintf("repos/%s/pulls/%d", client.repository, number), data, nil)
+}
+
+func (client *GithubClient) AddComment(number int, body string) error {
+	data := map[string]any{
+		"body": body,
+	}
+
+	return client.api.request("POST", fmt.Sprintf("repos/%s/issues/%d/comments", client.repository, number), data, nil)
+}
+
+func (client *GithubClient) AddLabels(number int, labels []string) error {
+	if len(labels) == 0 {
+		return nil
+	}
+
+	data := map[string]any{
+		"labels": labels,
+	}
+
+	return client.api.request("POST", fmt.Sprintf("repos/%s/issues/%d/labels", client.repository, number), data, nil)
+}
+
+func (client *GithubClient) AddReviewers(number int, reviewers []string) error {
+	if len(reviewers) == 0 {
+		return nil
+	}
+
+	data := map[string]any{
+		"reviewers": reviewers,
+	}
+
+	return client.api.request("POST", fmt.Sprintf("repos/%s/pulls/%d/requested_reviewers", client.repository, number), data, nil)
+}
+
+func (pr *githubPullRequest) toPullRequest() *PullRequest {
+	state := StateClosed
+
+	if pr.MergedAt != nil {
+		state = StateMerged
+	} else if pr.State == "open" {
+		state = StateOpen
+	}
+
+	return &PullRequest{
+		Number: pr.Number,
+		State:  state,
+		Url:    pr.HtmlUrl,
+	}
+}
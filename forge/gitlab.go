@@ -0,0 +1,188 @@
+package forge
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+type GitlabClient struct {
+	api     *apiClient
+	project string
+}
+
+type gitlabMergeRequest struct {
+	Iid             int    `json:"iid"`
+	State           string `json:"state"`
+	WebUrl          string `json:"web_url"`
+	SourceProjectId int    `json:"source_project_id"`
+}
+
+type gitlabProject struct {
+	Id int `json:"id"`
+}
+
+type gitlabUser struct {
+	Id int `json:"id"`
+}
+
+func NewGitlabClient(apiUrl string, project string, token string) *GitlabClient {
+	api := newApiClient(apiUrl)
+
+	if token != "" {
+		api.headers["PRIVATE-TOKEN"] = token
+	}
+
+	return &GitlabClient{
+		api:     api,
+		project: project,
+	}
+}
+
+func (client *GitlabClient) CreatePullRequest(options *PullRequestOptions) (*PullRequest, error) {
+	sourceProject := client.project
+
+	data := map[string]any{
+		"source_branch": options.HeadBranch,
+		"target_branch": options.BaseBranch,
+		"title":         options.Title,
+		"description":   options.Body,
+	}
+
+	if options.HeadRepository != "" && !strings.EqualFold(options.HeadRepository, client.project) {
+		targetProjectId, err := client.getProjectId(client.project)
+
+		if err != nil {
+			return nil, err
+		}
+
+		sourceProject = options.HeadRepository
+		data["target_project_id"] = targetProjectId
+	}
+
+	mr := gitlabMergeRequest{}
+
+	if err := client.api.request("POST", fmt.Sprintf("projects/%s/merge_requests", url.PathEscape(sourceProject)), data, &mr); err != nil {
+		return nil, err
+	}
+
+	return mr.toPullRequest(), nil
+}
+
+func (client *GitlabClient) FindPullRequest(headRepository string, headBranch string) (*PullRequest, error) {
+	if headRepository == "" {
+		headRepository = client.project
+	}
+
+	headProjectId, err := client.getProjectId(headRepository)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result *PullRequest
+
+	for page := 1; ; page++ {
+		var mrs []*gitlabMergeRequest
+
+		if err := client.api.request("GET", fmt.Sprintf("projects/%s/merge_requests?state=all&source_branch=%s&per_page=100&page=%d", url.PathEscape(client.project), url.QueryEscape(headBranch), page), nil, &mrs); err != nil {
+			return nil, err
+		}
+
+		if len(mrs) == 0 {
+			break
+		}
+
+		for _, mr := range mrs {
+			if mr.SourceProjectId == headProjectId {
+				result = preferPullRequest(result, mr.toPullRequest())
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func (client *GitlabClient) ClosePullRequest(number int) error {
+	data := map[string]any{
+		"state_event": "close",
+	}
+
+	return client.api.request("PUT", fmt.Sprintf("projects/%s/merge_requests/%d", url.PathEscape(client.project), number), data, nil)
+}
+
+func (client *GitlabClient) AddComment(number int, body string) error {
+	data := map[string]any{
+		"body": body,
+	}
+
+	return client.api.request("POST", fmt.Sprintf("projects/%s/merge_requests/%d/notes", url.PathEscape(client.project), number), data, nil)
+}
+
+func (client *GitlabClient) AddLabels(number int, labels []string) error {
+	if len(labels) == 0 {
+		return nil
+	}
+
+	data := map[string]any{
+		"add_labels": strings.Join(labels, ","),
+	}
+
+	return client.api.request("PUT", fmt.Sprintf("projects/%s/merge_requests/%d", url.PathEscape(client.project), number), data, nil)
+}
+
+func (client *GitlabClient) AddReviewers(number int, reviewers []string) error {
+	if len(reviewers) == 0 {
+		return nil
+	}
+
+	var reviewerIds []int
+
+	for _, reviewer := range reviewers {
+		var users []*gitlabUser
+
+		if err := client.api.request("GET", fmt.Sprintf("users?username=%s", url.QueryEscape(reviewer)), nil, &users); err != nil {
+			return err
+		}
+
+		if len(users) == 0 {
+			return errors.New(fmt.Sprintf("gitlab user %s does not exist", reviewer))
+		}
+
+		reviewerIds = append(reviewerIds, users[0].Id)
+	}
+
+	data := map[string]any{
+		"reviewer_ids": reviewerIds,
+	}
+
+	return client.api.request("PUT", fmt.Sprintf("projects/%s/merge_requests/%d", url.PathEscape(client.project), number), data, nil)
+}
+
+func (client *GitlabClient) getProjectId(project string) (int, error) {
+	result := gitlabProject{}
+
+	if err := client.api.request("GET", fmt.Sprintf("projects/%s", url.PathEscape(project)), nil, &result); err != nil {
+		return 0, err
+	}
+
+	return result.Id, nil
+}
+
+func (mr *gitlabMergeRequest) toPullRequest() *PullRequest {
+	state := StateClosed
+
+	switch mr.State {
+	case "opened":
+		state = StateOpen
+	case "merged":
+		state = StateMerged
+	}
+
+	return &PullRequest{
+		Number: mr.Iid,
+		State:  state,
+		Url:    mr.WebUrl,
+	}
+}
@@ -0,0 +1,128 @@
+package forge
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+var insecureSkipTls = false
+
+func init() {
+	if val, ok := os.LookupEnv("GIT_SSL_NO_VERIFY"); ok {
+		insecureSkipTls = strings.ToLower(val) == "true" || val == "1"
+	}
+}
+
+type apiClient struct {
+	baseUrl  string
+	headers  map[string]string
+	username string
+	password string
+	client   *http.Client
+}
+
+func newApiClient(baseUrl string) *apiClient {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if insecureSkipTls {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	return &apiClient{
+		baseUrl: strings.TrimSuffix(baseUrl, "/"),
+		headers: map[string]string{},
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   time.Minute,
+		},
+	}
+}
+
+func (client *apiClient) request(method string, path string, data any, result any) error {
+	var body io.Reader
+
+	if data != nil {
+		dataBytes, err := json.Marshal(data)
+
+		if err != nil {
+			return err
+		}
+
+		body = bytes.NewReader(dataBytes)
+	}
+
+	url := client.baseUrl + "/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequest(method, url, body)
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	for key, value := range client.headers {
+		req.Header.Set(key, value)
+	}
+
+	if client.username != "" || client.password != "" {
+		req.SetBasicAuth(client.username, client.password)
+	}
+
+	resp, err := client.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	respBytes, err := io.ReadAll(resp.Body)
+
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &ApiError{
+			Method:     method,
+			Url:        url,
+			StatusCode: resp.StatusCode,
+			Message:    getErrorMessage(respBytes),
+		}
+	}
+
+	if result == nil || len(respBytes) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(respBytes, result)
+}
+
+func getErrorMessage(body []byte) string {
+	message := struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}{}
+
+	if err := json.Unmarshal(body, &message); err == nil {
+		if message.Message != "" {
+			return message.Message
+		}
+
+		if message.Error != "" {
+			return message.Error
+		}
+	}
+
+	return strings.TrimSpace(string(body))
+}
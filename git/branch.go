@@ -1,16 +1,34 @@
 package git
 
 import (
+	"errors"
 	"fmt"
 	"github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/config"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/ryanpetris/aur-builder/cienv"
+	"github.com/ryanpetris/aur-builder/config"
+	"github.com/ryanpetris/aur-builder/pacman"
+	"log/slog"
+	"slices"
+	"strings"
 )
-import "github.com/go-git/go-git/v5/plumbing"
+
+const maintainerBranchPrefix = "aur-maintainer-"
+
+func GetPackageBranchName(pkgbase string, pkgver string) (string, error) {
+	return config.RenderBranchName(pkgbase, CleanTagName(pkgver))
+}
 
 func PackageUpdateBranchExists(pkgbase string, pkgver string) (bool, error) {
-	remotePath := fmt.Sprintf("origin/packages/%s/%s", pkgbase, CleanTagName(pkgver))
-	repo, err := git.PlainOpen(".")
+	branchName, err := GetPackageBranchName(pkgbase, pkgver)
+
+	if err != nil {
+		return false, err
+	}
+
+	remotePath := fmt.Sprintf("%s/%s", config.GetPushRemote(), branchName)
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
 
 	if err != nil {
 		return false, err
@@ -21,75 +39,219 @@ func PackageUpdateBranchExists(pkgbase string, pkgver string) (bool, error) {
 	return err == nil, nil
 }
 
-func CreateAndSwitchToPackageUpdateBranch(pkgbase string, pkgver string) error {
-	branchRef := plumbing.NewBranchReferenceName(fmt.Sprintf("packages/%s/%s", pkgbase, CleanTagName(pkgver)))
-	repo, err := git.PlainOpen(".")
+func PushPackageBranch(pkgbase string, pkgver string) error {
+	branchRef, err := GetPackageBranchName(pkgbase, pkgver)
 
 	if err != nil {
 		return err
 	}
 
-	headRef, err := repo.Head()
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
 
 	if err != nil {
 		return err
 	}
 
-	err = repo.Storer.SetReference(plumbing.NewHashReference(branchRef, headRef.Hash()))
-
-	if err != nil {
-		return err
+	pushOptions := git.PushOptions{
+		RemoteName:      config.GetPushRemote(),
+		RefSpecs:        []gitconfig.RefSpec{gitconfig.RefSpec(fmt.Sprintf("+refs/heads/%s:refs/heads/%s", branchRef, branchRef))},
+		InsecureSkipTLS: insecureSkipTls,
 	}
 
-	worktree, err := repo.Worktree()
+	ce := cienv.FindCiEnv()
 
-	if err != nil {
+	if err := ce.SetGitPushOptions(&pushOptions); err != nil {
 		return err
 	}
 
-	if err := worktree.Checkout(&git.CheckoutOptions{
-		Branch: branchRef,
-		Keep:   true,
-	}); err != nil {
+	if err := repo.Push(&pushOptions); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func SwitchToMaster() error {
-	repo, err := git.PlainOpen(".")
+func GetPackageBranches(pkgbases []string) (map[string][]string, error) {
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	worktree, err := repo.Worktree()
+	remote, err := repo.Remote(config.GetPushRemote())
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if err := worktree.Checkout(&git.CheckoutOptions{
-		Branch: plumbing.NewBranchReferenceName("master"),
-	}); err != nil {
-		return err
+	pushOptions := git.PushOptions{}
+	ce := cienv.FindCiEnv()
+
+	if err := ce.SetGitPushOptions(&pushOptions); err != nil {
+		return nil, err
 	}
 
-	return nil
+	refs, err := remote.List(&git.ListOptions{
+		Auth:            pushOptions.Auth,
+		InsecureSkipTLS: insecureSkipTls,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	patterns := map[string][]string{}
+
+	for _, pkgbase := range pkgbases {
+		pattern, err := getPackageBranchPattern(pkgbase)
+
+		if err != nil {
+			return nil, err
+		}
+
+		patterns[pkgbase] = pattern
+	}
+
+	result := map[string][]string{}
+
+	for _, ref := range refs {
+		if !ref.Name().IsBranch() {
+			continue
+		}
+
+		branchName := ref.Name().Short()
+		match, matchLength := "", 0
+
+		for pkgbase, pattern := range patterns {
+			if len(pattern) == 1 {
+				if branchName == pattern[0] && len(pattern[0]) > matchLength {
+					match, matchLength = pkgbase, len(pattern[0])
+				}
+			} else if len(branchName) > len(pattern[0])+len(pattern[1]) && strings.HasPrefix(branchName, pattern[0]) && strings.HasSuffix(branchName, pattern[1]) && len(pattern[0]) > matchLength {
+				match, matchLength = pkgbase, len(pattern[0])
+			}
+		}
+
+		if match != "" {
+			result[match] = append(result[match], branchName)
+		}
+	}
+
+	for _, branches := range result {
+		slices.Sort(branches)
+	}
+
+	return result, nil
 }
 
-func PushPackageBranch(pkgbase string, pkgver string) error {
-	branchRef := fmt.Sprintf("packages/%s/%s", pkgbase, CleanTagName(pkgver))
-	repo, err := git.PlainOpen(".")
+func getPackageBranchPattern(pkgbase string) ([]string, error) {
+	pattern, err := config.RenderBranchName(pkgbase, "\x00")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.SplitN(pattern, "\x00", 2), nil
+}
+
+// GetPackageBranchVersion returns the package version a branch was created
+// for, reversing the changes made by CleanTagName. Branches recording an AUR
+// maintainer have no version.
+func GetPackageBranchVersion(pkgbase string, branchName string) (string, bool, error) {
+	pattern, err := getPackageBranchPattern(pkgbase)
+
+	if err != nil {
+		return "", false, err
+	}
+
+	version, ok := getBranchVersion(pattern, branchName)
+
+	if !ok || strings.HasPrefix(version, maintainerBranchPrefix) {
+		return "", false, nil
+	}
+
+	// pkgver and pkgrel can't contain hyphens, so a third part is the epoch.
+	if strings.Count(version, "-") == 2 {
+		version = strings.Replace(version, "-", ":", 1)
+	}
+
+	return version, true, nil
+}
+
+func getBranchVersion(pattern []string, branchName string) (string, bool) {
+	if len(pattern) != 2 || len(branchName) <= len(pattern[0])+len(pattern[1]) || !strings.HasPrefix(branchName, pattern[0]) || !strings.HasSuffix(branchName, pattern[1]) {
+		return "", false
+	}
+
+	return strings.TrimSuffix(strings.TrimPrefix(branchName, pattern[0]), pattern[1]), true
+}
+
+// GetMaintainerBranchVersion returns the value used in place of the version
+// in the name of the branch recording a new AUR maintainer.
+func GetMaintainerBranchVersion(maintainer string) string {
+	if maintainer == "" {
+		return maintainerBranchPrefix + "orphaned"
+	}
+
+	return maintainerBranchPrefix + "by-" + maintainer
+}
+
+// GetSupersededMaintainerBranches returns the branches of a package that
+// recorded an AUR maintainer other than maintainer.
+func GetSupersededMaintainerBranches(pkgbase string, maintainer string, branches []string) ([]string, error) {
+	pattern, err := getPackageBranchPattern(pkgbase)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+
+	for _, branchName := range branches {
+		if version, ok := getBranchVersion(pattern, branchName); ok && strings.HasPrefix(version, maintainerBranchPrefix) && version != CleanTagName(GetMaintainerBranchVersion(maintainer)) {
+			result = append(result, branchName)
+		}
+	}
+
+	return result, nil
+}
+
+// GetSupersededBranches returns the branches of a package that were created
+// for versions older than pkgver.
+func GetSupersededBranches(pkgbase string, pkgver string, branches []string) ([]string, error) {
+	var result []string
+
+	for _, branchName := range branches {
+		branchVersion, ok, err := GetPackageBranchVersion(pkgbase, branchName)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		if compare, err := pacman.CompareVersions(branchVersion, pkgver); err != nil {
+			slog.Warn(fmt.Sprintf("Not superseding branch %s, unable to compare versions: %s", branchName, err))
+		} else if compare < 0 {
+			result = append(result, branchName)
+		}
+	}
+
+	return result, nil
+}
+
+func DeleteRemoteBranch(branchName string) error {
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
 
 	if err != nil {
 		return err
 	}
 
 	pushOptions := git.PushOptions{
-		RemoteName:      "origin",
-		RefSpecs:        []config.RefSpec{config.RefSpec(fmt.Sprintf("+refs/heads/%s:refs/heads/%s", branchRef, branchRef))},
+		RemoteName:      config.GetPushRemote(),
+		RefSpecs:        []gitconfig.RefSpec{gitconfig.RefSpec(fmt.Sprintf(":refs/heads/%s", branchName))},
 		InsecureSkipTLS: insecureSkipTls,
 	}
 
@@ -99,9 +261,29 @@ func PushPackageBranch(pkgbase string, pkgver string) error {
 		return err
 	}
 
-	if err := repo.Push(&pushOptions); err != nil {
+	if err := repo.Push(&pushOptions); err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
 		return err
 	}
 
+	remoteRef := plumbing.NewRemoteReferenceName(config.GetPushRemote(), branchName)
+
+	if err := repo.Storer.RemoveReference(remoteRef); err != nil {
+		return err
+	}
+
+	head, err := repo.Head()
+
+	if err != nil {
+		return err
+	}
+
+	localRef := plumbing.NewBranchReferenceName(branchName)
+
+	if head.Name() != localRef {
+		if err := repo.Storer.RemoveReference(localRef); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,125 @@
+package git
+
+import (
+	"github.com/ryanpetris/aur-builder/config"
+	"slices"
+	"testing"
+)
+
+func setBranchTemplate(t *testing.T, template string) {
+	cfg := config.GetGlobalConfig()
+	old := cfg.BranchTemplate
+	cfg.BranchTemplate = template
+
+	t.Cleanup(func() {
+		cfg.BranchTemplate = old
+	})
+}
+
+func TestGetPackageBranchVersion(t *testing.T) {
+	tests := []struct {
+		template string
+		branch   string
+		expected string
+		ok       bool
+	}{
+		{"", "packages/foo/1.2.3-1", "1.2.3-1", true},
+		{"", "packages/foo/2-1.2.3-1", "2:1.2.3-1", true},
+		{"", "packages/foo/1.2.3.r4.gabcdef-1.1", "1.2.3.r4.gabcdef-1.1", true},
+		{"", "packages/foo-git/1.0-1", "", false},
+		{"", "packages/foo/", "", false},
+		{"", "main", "", false},
+		{"", "packages/foo/aur-maintainer-by-some-one", "", false},
+		{"update/{{ .Version }}/{{ .Pkgbase }}", "update/1-2.0-3/foo", "1:2.0-3", true},
+		{"update/{{ .Version }}/{{ .Pkgbase }}", "update/1-2.0-3/bar", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.branch, func(t *testing.T) {
+			setBranchTemplate(t, test.template)
+
+			version, ok, err := GetPackageBranchVersion("foo", test.branch)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if version != test.expected || ok != test.ok {
+				t.Errorf("expected %q (%t), got %q (%t)", test.expected, test.ok, version, ok)
+			}
+		})
+	}
+}
+
+func TestGetSupersededBranches(t *testing.T) {
+	setBranchTemplate(t, "")
+
+	branches := []string{
+		"packages/foo/1.0-1",
+		"packages/foo/1.1-1",
+		"packages/foo/1.1-2",
+		"packages/foo/1.2.r3.gabc-1",
+		"packages/foo/1-0.9-1",
+		"packages/foo/1.0-rc",
+		"packages/foo/aur-maintainer-orphaned",
+	}
+
+	tests := []struct {
+		name     string
+		pkgver   string
+		expected []string
+	}{
+		{"newest", "1:0.9-2", []string{"packages/foo/1.0-1", "packages/foo/1.1-1", "packages/foo/1.1-2", "packages/foo/1.2.r3.gabc-1", "packages/foo/1-0.9-1"}},
+		{"middle", "1.1-2", []string{"packages/foo/1.0-1", "packages/foo/1.1-1"}},
+		{"oldest", "1.0-1", nil},
+		{"older than all", "0.1-1", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := GetSupersededBranches("foo", test.pkgver, branches)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(result, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestGetSupersededMaintainerBranches(t *testing.T) {
+	setBranchTemplate(t, "")
+
+	branches := []string{
+		"packages/foo/1.0-1",
+		"packages/foo/aur-maintainer-by-alice",
+		"packages/foo/aur-maintainer-by-bob",
+		"packages/foo/aur-maintainer-orphaned",
+	}
+
+	tests := []struct {
+		maintainer string
+		expected   []string
+	}{
+		{"alice", []string{"packages/foo/aur-maintainer-by-bob", "packages/foo/aur-maintainer-orphaned"}},
+		{"", []string{"packages/foo/aur-maintainer-by-alice", "packages/foo/aur-maintainer-by-bob"}},
+		{"carol", []string{"packages/foo/aur-maintainer-by-alice", "packages/foo/aur-maintainer-by-bob", "packages/foo/aur-maintainer-orphaned"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.maintainer, func(t *testing.T) {
+			result, err := GetSupersededMaintainerBranches("foo", test.maintainer, branches)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(result, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, result)
+			}
+		})
+	}
+}
@@ -9,7 +9,7 @@ import (
 	"path"
 )
 
-func CloneUpstream(pkgbase string, url string, tag string) error {
+func CloneUpstream(pkgbase string, url string, tag string) (string, error) {
 	upstreamPath := config.GetUpstreamPath(pkgbase)
 	removePaths := []string{
 		".git",
@@ -18,7 +18,7 @@ func CloneUpstream(pkgbase string, url string, tag string) error {
 
 	if _, err := os.Stat(upstreamPath); err != nil {
 		if err = os.RemoveAll(upstreamPath); err != nil {
-			return err
+			return "", err
 		}
 	}
 
@@ -33,10 +33,16 @@ func CloneUpstream(pkgbase string, url string, tag string) error {
 		cloneOptions.SingleBranch = true
 	}
 
-	_, err := git.PlainClone(upstreamPath, false, cloneOptions)
+	repo, err := git.PlainClone(upstreamPath, false, cloneOptions)
 
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	head, err := repo.Head()
+
+	if err != nil {
+		return "", err
 	}
 
 	for _, item := range removePaths {
@@ -47,9 +53,9 @@ func CloneUpstream(pkgbase string, url string, tag string) error {
 		}
 
 		if err = os.RemoveAll(removePath); err != nil {
-			return err
+			return "", err
 		}
 	}
 
-	return nil
+	return head.Hash().String(), nil
 }
@@ -1,43 +1,91 @@
 package git
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
 	"github.com/ryanpetris/aur-builder/cienv"
+	"github.com/ryanpetris/aur-builder/config"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 )
 
-func AddAll() error {
-	repo, err := git.PlainOpen(".")
+func CommitPackage(pkgbase string, pkgver string, message string) error {
+	branchName, err := GetPackageBranchName(pkgbase, pkgver)
 
 	if err != nil {
 		return err
 	}
 
-	worktree, err := repo.Worktree()
+	repo, root, err := openRepository()
 
 	if err != nil {
 		return err
 	}
 
-	if _, err = worktree.Add("."); err != nil {
+	baseCommit, err := getBaseCommit(repo)
+
+	if err != nil {
 		return err
 	}
 
-	return nil
-}
+	baseTree, err := baseCommit.Tree()
+
+	if err != nil {
+		return err
+	}
 
-func Commit(message string) error {
-	repo, err := git.PlainOpen(".")
+	pathParts, err := getPackagePathParts(root, pkgbase)
 
 	if err != nil {
 		return err
 	}
 
-	worktree, err := repo.Worktree()
+	baseFiles, err := getTreeFiles(baseTree, pathParts)
+
+	if err != nil {
+		return err
+	}
+
+	patterns, err := readAncestorIgnorePatterns(root, pathParts)
 
 	if err != nil {
 		return err
 	}
 
+	packageHash, err := writeDirectoryTree(repo.Storer, root, pathParts, patterns, baseFiles)
+
+	if err != nil {
+		return err
+	}
+
+	treeHash, err := replaceTreePath(repo.Storer, baseTree, pathParts, packageHash)
+
+	if err != nil {
+		return err
+	}
+
+	if treeHash == plumbing.ZeroHash {
+		if treeHash, err = writeTree(repo.Storer, nil); err != nil {
+			return err
+		}
+	}
+
+	if treeHash == baseTree.Hash {
+		return errors.New(fmt.Sprintf("no changes to commit for package %s", pkgbase))
+	}
+
 	options := git.CommitOptions{}
 	ce := cienv.FindCiEnv()
 
@@ -45,9 +93,499 @@ func Commit(message string) error {
 		return err
 	}
 
-	if _, err = worktree.Commit(message, &options); err != nil {
+	if err := setCommitSigningOptions(&options); err != nil {
+		return err
+	}
+
+	options.Parents = []plumbing.Hash{baseCommit.Hash}
+
+	if err := options.Validate(repo); err != nil {
+		return err
+	}
+
+	commit := &object.Commit{
+		Author:       *options.Author,
+		Committer:    *options.Committer,
+		Message:      message,
+		TreeHash:     treeHash,
+		ParentHashes: options.Parents,
+	}
+
+	if commit.Author.When.IsZero() {
+		commit.Author.When = time.Now()
+	}
+
+	if commit.Committer.When.IsZero() {
+		commit.Committer.When = commit.Author.When
+	}
+
+	if err := signCommit(commit, &options); err != nil {
+		return err
+	}
+
+	commitHash, err := writeObject(repo.Storer, commit)
+
+	if err != nil {
+		return err
+	}
+
+	return repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(branchName), commitHash))
+}
+
+func RestorePackage(pkgbase string) error {
+	repo, root, err := openRepository()
+
+	if err != nil {
+		return err
+	}
+
+	baseCommit, err := getBaseCommit(repo)
+
+	if err != nil {
+		return err
+	}
+
+	baseTree, err := baseCommit.Tree()
+
+	if err != nil {
+		return err
+	}
+
+	pathParts, err := getPackagePathParts(root, pkgbase)
+
+	if err != nil {
 		return err
 	}
 
+	baseFiles, err := getTreeFiles(baseTree, pathParts)
+
+	if err != nil {
+		return err
+	}
+
+	patterns, err := readAncestorIgnorePatterns(root, pathParts)
+
+	if err != nil {
+		return err
+	}
+
+	if err := removeUntrackedFiles(root, pathParts, patterns, baseFiles); err != nil {
+		return err
+	}
+
+	for name, file := range baseFiles {
+		if err := restoreFile(filepath.Join(root, filepath.FromSlash(name)), file); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+func openRepository() (*git.Repository, string, error) {
+	repo, err := git.PlainOpenWithOptions(".", &git.PlainOpenOptions{DetectDotGit: true})
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	worktree, err := repo.Worktree()
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return repo, worktree.Filesystem.Root(), nil
+}
+
+func getBaseCommit(repo *git.Repository) (*object.Commit, error) {
+	hash, err := repo.ResolveRevision(plumbing.Revision(config.GetBaseBranch()))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return repo.CommitObject(*hash)
+}
+
+func getPackagePathParts(root string, pkgbase string) ([]string, error) {
+	relPath, err := filepath.Rel(root, config.GetPackagePath(pkgbase))
+
+	if err != nil {
+		return nil, err
+	}
+
+	relPath = filepath.ToSlash(relPath)
+
+	if relPath == "." || relPath == ".." || strings.HasPrefix(relPath, "../") {
+		return nil, errors.New(fmt.Sprintf("package path for %s is not inside the repository", pkgbase))
+	}
+
+	return strings.Split(relPath, "/"), nil
+}
+
+func getTreeFiles(tree *object.Tree, pathParts []string) (map[string]*object.File, error) {
+	result := map[string]*object.File{}
+	subtree, err := tree.Tree(strings.Join(pathParts, "/"))
+
+	if errors.Is(err, object.ErrDirectoryNotFound) {
+		return result, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	prefix := strings.Join(pathParts, "/") + "/"
+
+	err = subtree.Files().ForEach(func(file *object.File) error {
+		result[prefix+file.Name] = file
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func readAncestorIgnorePatterns(root string, pathParts []string) ([]gitignore.Pattern, error) {
+	patterns, err := readIgnoreFile(filepath.Join(root, ".git", "info", "exclude"), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < len(pathParts); i++ {
+		dirPatterns, err := readIgnoreFile(filepath.Join(root, filepath.Join(pathParts[:i]...), ".gitignore"), pathParts[:i])
+
+		if err != nil {
+			return nil, err
+		}
+
+		patterns = append(patterns, dirPatterns...)
+	}
+
+	return patterns, nil
+}
+
+func readIgnoreFile(filePath string, domain []string) ([]gitignore.Pattern, error) {
+	file, err := os.Open(filePath)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	var result []gitignore.Pattern
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		result = append(result, gitignore.ParsePattern(line, slices.Clone(domain)))
+	}
+
+	return result, scanner.Err()
+}
+
+func writeDirectoryTree(s storer.EncodedObjectStorer, root string, pathParts []string, patterns []gitignore.Pattern, baseFiles map[string]*object.File) (plumbing.Hash, error) {
+	dirPath := filepath.Join(root, filepath.Join(pathParts...))
+	dirPatterns, err := readIgnoreFile(filepath.Join(dirPath, ".gitignore"), pathParts)
+
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	patterns = append(slices.Clone(patterns), dirPatterns...)
+	matcher := gitignore.NewMatcher(patterns)
+	dirEntries, err := os.ReadDir(dirPath)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return plumbing.ZeroHash, nil
+	} else if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	var entries []object.TreeEntry
+
+	for _, dirEntry := range dirEntries {
+		if dirEntry.Name() == ".git" {
+			continue
+		}
+
+		entryParts := append(slices.Clone(pathParts), dirEntry.Name())
+		entryPath := filepath.Join(dirPath, dirEntry.Name())
+
+		if dirEntry.IsDir() {
+			if matcher.Match(entryParts, true) && !hasTreeFilesWithPrefix(baseFiles, strings.Join(entryParts, "/")+"/") {
+				continue
+			}
+
+			hash, err := writeDirectoryTree(s, root, entryParts, patterns, baseFiles)
+
+			if err != nil {
+				return plumbing.ZeroHash, err
+			}
+
+			if hash != plumbing.ZeroHash {
+				entries = append(entries, object.TreeEntry{Name: dirEntry.Name(), Mode: filemode.Dir, Hash: hash})
+			}
+
+			continue
+		}
+
+		if _, tracked := baseFiles[strings.Join(entryParts, "/")]; matcher.Match(entryParts, false) && !tracked {
+			continue
+		}
+
+		info, err := os.Lstat(entryPath)
+
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		mode, err := filemode.NewFromOSFileMode(info.Mode())
+
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		var content []byte
+
+		if mode == filemode.Symlink {
+			target, err := os.Readlink(entryPath)
+
+			if err != nil {
+				return plumbing.ZeroHash, err
+			}
+
+			content = []byte(target)
+		} else if content, err = os.ReadFile(entryPath); err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		hash, err := writeBlob(s, content)
+
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+
+		entries = append(entries, object.TreeEntry{Name: dirEntry.Name(), Mode: mode, Hash: hash})
+	}
+
+	if len(entries) == 0 {
+		return plumbing.ZeroHash, nil
+	}
+
+	return writeTree(s, entries)
+}
+
+func hasTreeFilesWithPrefix(files map[string]*object.File, prefix string) bool {
+	for name := range files {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func replaceTreePath(s storer.EncodedObjectStorer, tree *object.Tree, pathParts []string, hash plumbing.Hash) (plumbing.Hash, error) {
+	var entries []object.TreeEntry
+	var existing *object.TreeEntry
+
+	if tree != nil {
+		for _, entry := range tree.Entries {
+			if entry.Name == pathParts[0] {
+				existing = &entry
+				continue
+			}
+
+			entries = append(entries, entry)
+		}
+	}
+
+	if len(pathParts) > 1 {
+		var subtree *object.Tree
+
+		var err error
+
+		if existing != nil && existing.Mode == filemode.Dir {
+			if subtree, err = object.GetTree(s, existing.Hash); err != nil {
+				return plumbing.ZeroHash, err
+			}
+		}
+
+		if hash, err = replaceTreePath(s, subtree, pathParts[1:], hash); err != nil {
+			return plumbing.ZeroHash, err
+		}
+	}
+
+	if hash != plumbing.ZeroHash {
+		entries = append(entries, object.TreeEntry{Name: pathParts[0], Mode: filemode.Dir, Hash: hash})
+	}
+
+	if len(entries) == 0 {
+		return plumbing.ZeroHash, nil
+	}
+
+	return writeTree(s, entries)
+}
+
+func writeTree(s storer.EncodedObjectStorer, entries []object.TreeEntry) (plumbing.Hash, error) {
+	sortKey := func(entry object.TreeEntry) string {
+		if entry.Mode == filemode.Dir {
+			return entry.Name + "/"
+		}
+
+		return entry.Name
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return sortKey(entries[i]) < sortKey(entries[j])
+	})
+
+	return writeObject(s, &object.Tree{Entries: entries})
+}
+
+func writeBlob(s storer.EncodedObjectStorer, content []byte) (plumbing.Hash, error) {
+	obj := s.NewEncodedObject()
+	obj.SetType(plumbing.BlobObject)
+
+	writer, err := obj.Writer()
+
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	if _, err := writer.Write(content); err != nil {
+		writer.Close()
+		return plumbing.ZeroHash, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	return s.SetEncodedObject(obj)
+}
+
+func writeObject(s storer.EncodedObjectStorer, encoder interface {
+	Encode(plumbing.EncodedObject) error
+}) (plumbing.Hash, error) {
+	obj := s.NewEncodedObject()
+
+	if err := encoder.Encode(obj); err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	return s.SetEncodedObject(obj)
+}
+
+func removeUntrackedFiles(root string, pathParts []string, patterns []gitignore.Pattern, baseFiles map[string]*object.File) error {
+	dirPath := filepath.Join(root, filepath.Join(pathParts...))
+	dirPatterns, err := readIgnoreFile(filepath.Join(dirPath, ".gitignore"), pathParts)
+
+	if err != nil {
+		return err
+	}
+
+	patterns = append(slices.Clone(patterns), dirPatterns...)
+	matcher := gitignore.NewMatcher(patterns)
+	dirEntries, err := os.ReadDir(dirPath)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, dirEntry := range dirEntries {
+		if dirEntry.Name() == ".git" {
+			continue
+		}
+
+		entryParts := append(slices.Clone(pathParts), dirEntry.Name())
+		entryName := strings.Join(entryParts, "/")
+
+		if matcher.Match(entryParts, dirEntry.IsDir()) {
+			continue
+		}
+
+		if dirEntry.IsDir() {
+			if err := removeUntrackedFiles(root, entryParts, patterns, baseFiles); err != nil {
+				return err
+			}
+
+			if remaining, err := os.ReadDir(filepath.Join(dirPath, dirEntry.Name())); err == nil && len(remaining) == 0 {
+				if err := os.Remove(filepath.Join(dirPath, dirEntry.Name())); err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		if _, tracked := baseFiles[entryName]; !tracked {
+			if err := os.Remove(filepath.Join(dirPath, dirEntry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func restoreFile(filePath string, file *object.File) error {
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return err
+	}
+
+	reader, err := file.Reader()
+
+	if err != nil {
+		return err
+	}
+
+	defer reader.Close()
+
+	if err := os.Remove(filePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if file.Mode == filemode.Symlink {
+		target, err := io.ReadAll(reader)
+
+		if err != nil {
+			return err
+		}
+
+		return os.Symlink(string(target), filePath)
+	}
+
+	perm := os.FileMode(0644)
+
+	if file.Mode == filemode.Executable {
+		perm = 0755
+	}
+
+	output, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(output, reader); err != nil {
+		output.Close()
+		return err
+	}
+
+	return output.Close()
+}
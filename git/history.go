@@ -0,0 +1,313 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/ryanpetris/aur-builder/config"
+	"slices"
+	"strings"
+	"time"
+)
+
+const maxUpstreamLogEntries = 50
+
+type CommitInfo struct {
+	Hash  string
+	Paths []string
+	Tags  []string
+}
+
+func GetCommitsBetween(path string, from string, to string) ([]*CommitInfo, error) {
+	repo, err := git.PlainOpen(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	commits, err := getCommitRange(repo, plumbing.NewHash(from), plumbing.NewHash(to))
+
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := getTaggedCommits(repo)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*CommitInfo
+
+	for _, commit := range commits {
+		paths, err := getChangedPaths(commit)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &CommitInfo{
+			Hash:  commit.Hash.String(),
+			Paths: paths,
+			Tags:  tags[commit.Hash],
+		})
+	}
+
+	return result, nil
+}
+
+// getCommitRange returns the commits reachable from to but not from from,
+// newest first. Like git rev-list, both histories are walked together by
+// commit time and the walk stops once only commits reachable from from are
+// left, so only the history down to the merge base is read.
+func getCommitRange(repo *git.Repository, from plumbing.Hash, to plumbing.Hash) ([]*object.Commit, error) {
+	fromCommit, err := repo.CommitObject(from)
+
+	if err != nil {
+		return nil, err
+	}
+
+	toCommit, err := repo.CommitObject(to)
+
+	if err != nil {
+		return nil, err
+	}
+
+	visited := map[plumbing.Hash]*object.Commit{}
+	excluded := map[plumbing.Hash]bool{from: true}
+	queued := map[plumbing.Hash]bool{from: true, to: true}
+	queue := []*object.Commit{fromCommit, toCommit}
+
+	var markExcluded func(hash plumbing.Hash)
+
+	markExcluded = func(hash plumbing.Hash) {
+		if excluded[hash] {
+			return
+		}
+
+		excluded[hash] = true
+
+		if commit := visited[hash]; commit != nil {
+			for _, parent := range commit.ParentHashes {
+				markExcluded(parent)
+			}
+		}
+	}
+
+	var candidates []*object.Commit
+
+	for slices.ContainsFunc(queue, func(commit *object.Commit) bool { return !excluded[commit.Hash] }) {
+		newest := 0
+
+		for index, commit := range queue {
+			if commit.Committer.When.After(queue[newest].Committer.When) {
+				newest = index
+			}
+		}
+
+		commit := queue[newest]
+		queue = slices.Delete(queue, newest, newest+1)
+		visited[commit.Hash] = commit
+
+		if excluded[commit.Hash] {
+			for _, parent := range commit.ParentHashes {
+				markExcluded(parent)
+			}
+		} else {
+			candidates = append(candidates, commit)
+		}
+
+		for _, parent := range commit.ParentHashes {
+			if queued[parent] {
+				continue
+			}
+
+			queued[parent] = true
+			parentCommit, err := repo.CommitObject(parent)
+
+			if errors.Is(err, plumbing.ErrObjectNotFound) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+
+			queue = append(queue, parentCommit)
+		}
+	}
+
+	var result []*object.Commit
+
+	for _, commit := range candidates {
+		if !excluded[commit.Hash] {
+			result = append(result, commit)
+		}
+	}
+
+	return result, nil
+}
+
+func getChangedPaths(commit *object.Commit) ([]string, error) {
+	tree, err := commit.Tree()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var parentTree *object.Tree
+
+	if commit.NumParents() > 0 {
+		parent, err := commit.Parent(0)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, err
+		}
+	}
+
+	changes, err := object.DiffTree(parentTree, tree)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+
+	for _, change := range changes {
+		if change.From.Name != "" {
+			result = append(result, change.From.Name)
+		}
+
+		if change.To.Name != "" && change.To.Name != change.From.Name {
+			result = append(result, change.To.Name)
+		}
+	}
+
+	return result, nil
+}
+
+func getTaggedCommits(repo *git.Repository) (map[plumbing.Hash][]string, error) {
+	refs, err := repo.Tags()
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[plumbing.Hash][]string{}
+
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		hash := ref.Hash()
+
+		if tag, err := repo.TagObject(hash); err == nil {
+			commit, err := tag.Commit()
+
+			if err != nil {
+				return nil
+			}
+
+			hash = commit.Hash
+		} else if !errors.Is(err, plumbing.ErrObjectNotFound) {
+			return err
+		}
+
+		result[hash] = append(result[hash], ref.Name().Short())
+
+		return nil
+	})
+
+	if err != nil && !errors.Is(err, storer.ErrStop) {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func GetUpstreamLog(url string, from string, to string) ([]string, error) {
+	repo, err := openUpstreamLogRepository(url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	iter, err := repo.Log(&git.LogOptions{From: plumbing.NewHash(to)})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+
+	err = iter.ForEach(func(commit *object.Commit) error {
+		if commit.Hash.String() == from || len(result) >= maxUpstreamLogEntries {
+			return storer.ErrStop
+		}
+
+		subject := strings.SplitN(strings.TrimSpace(commit.Message), "\n", 2)[0]
+		result = append(result, fmt.Sprintf("%s %s", commit.Hash.String()[:8], subject))
+
+		return nil
+	})
+
+	if err != nil && !errors.Is(err, storer.ErrStop) && !errors.Is(err, plumbing.ErrObjectNotFound) {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// The log is read from the VCS mirror cache when one is configured. Otherwise
+// only as much history as can appear in the log is fetched.
+func openUpstreamLogRepository(url string) (*git.Repository, error) {
+	if config.GetVcsCachePath() != "" {
+		mirrorPath, err := UpdateMirror(url)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return git.PlainOpen(mirrorPath)
+	}
+
+	return git.Clone(memory.NewStorage(), nil, &git.CloneOptions{
+		URL:             url,
+		Depth:           maxUpstreamLogEntries + 1,
+		SingleBranch:    true,
+		Tags:            git.NoTags,
+		InsecureSkipTLS: insecureSkipTls,
+	})
+}
+
+func GetTagTime(url string, tag string) (time.Time, error) {
+	repo, err := git.Clone(memory.NewStorage(), nil, &git.CloneOptions{
+		URL:             url,
+		ReferenceName:   plumbing.NewTagReferenceName(CleanTagName(tag)),
+		SingleBranch:    true,
+		Depth:           1,
+		NoCheckout:      true,
+		InsecureSkipTLS: insecureSkipTls,
+	})
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	head, err := repo.Head()
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	commit, err := repo.CommitObject(head.Hash())
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return commit.Committer.When, nil
+}
@@ -0,0 +1,180 @@
+package git
+
+import (
+	"fmt"
+	"github.com/ryanpetris/aur-builder/config"
+	"os"
+	"os/exec"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+)
+
+type testRepository struct {
+	t     *testing.T
+	dir   string
+	clock int
+}
+
+func newTestRepository(t *testing.T) *testRepository {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	repo := &testRepository{t: t, dir: t.TempDir()}
+	repo.run("init", "--quiet", "--initial-branch=main")
+
+	return repo
+}
+
+func (repo *testRepository) run(args ...string) string {
+	repo.t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = repo.dir
+	cmd.Env = append(os.Environ(),
+		"GIT_CONFIG_GLOBAL=/dev/null",
+		"GIT_AUTHOR_NAME=test",
+		"GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test",
+		"GIT_COMMITTER_EMAIL=test@example.com",
+		fmt.Sprintf("GIT_AUTHOR_DATE=%d +0000", 1700000000+repo.clock),
+		fmt.Sprintf("GIT_COMMITTER_DATE=%d +0000", 1700000000+repo.clock),
+	)
+	out, err := cmd.CombinedOutput()
+
+	if err != nil {
+		repo.t.Fatalf("git %s failed: %s\n%s", strings.Join(args, " "), err, out)
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+func (repo *testRepository) commit(name string) string {
+	repo.t.Helper()
+
+	repo.clock++
+
+	if err := os.WriteFile(path.Join(repo.dir, name), []byte(name+"\n"), 0644); err != nil {
+		repo.t.Fatal(err)
+	}
+
+	repo.run("add", name)
+	repo.run("commit", "--quiet", "-m", name)
+
+	return repo.run("rev-parse", "HEAD")
+}
+
+func TestGetCommitsBetween(t *testing.T) {
+	repo := newTestRepository(t)
+
+	a := repo.commit("a")
+	b := repo.commit("b")
+	repo.run("checkout", "--quiet", "-b", "side", a)
+	c := repo.commit("c")
+	repo.run("checkout", "--quiet", "-b", "other", b)
+	d := repo.commit("d")
+	repo.run("tag", "v1")
+	repo.run("checkout", "--quiet", "side")
+	repo.clock++
+	repo.run("merge", "--quiet", "--no-ff", "-m", "merge", "other")
+	m := repo.run("rev-parse", "HEAD")
+
+	tests := []struct {
+		name     string
+		from     string
+		to       string
+		expected []string
+	}{
+		{"linear", a, b, []string{b}},
+		{"same commit", b, b, nil},
+		{"merge", b, m, []string{m, d, c}},
+		{"diverged", c, b, []string{b}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			commits, err := GetCommitsBetween(repo.dir, test.from, test.to)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var hashes []string
+
+			for _, commit := range commits {
+				hashes = append(hashes, commit.Hash)
+			}
+
+			if !slices.Equal(hashes, test.expected) {
+				t.Errorf("expected %v, got %v", test.expected, hashes)
+			}
+		})
+	}
+
+	commits, err := GetCommitsBetween(repo.dir, b, d)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(commits) != 1 || !slices.Equal(commits[0].Tags, []string{"v1"}) || !slices.Equal(commits[0].Paths, []string{"d"}) {
+		t.Errorf("expected tagged commit changing d, got %+v", commits)
+	}
+}
+
+func TestGetUpstreamLog(t *testing.T) {
+	repo := newTestRepository(t)
+
+	var hashes []string
+
+	for index := 0; index < maxUpstreamLogEntries+10; index++ {
+		hashes = append(hashes, repo.commit(fmt.Sprintf("file%d", index)))
+	}
+
+	head := hashes[len(hashes)-1]
+	url := "file://" + repo.dir
+
+	tests := []struct {
+		name     string
+		from     string
+		expected int
+	}{
+		{"bounded by from", hashes[len(hashes)-4], 3},
+		{"bounded by limit", hashes[0], maxUpstreamLogEntries},
+		{"unknown from", "0123456789abcdef0123456789abcdef01234567", maxUpstreamLogEntries},
+	}
+
+	t.Cleanup(func() {
+		config.GetGlobalConfig().VcsCachePath = ""
+	})
+
+	for _, cachePath := range []string{"", t.TempDir()} {
+		config.GetGlobalConfig().VcsCachePath = cachePath
+
+		for _, test := range tests {
+			t.Run(fmt.Sprintf("%s (cache %t)", test.name, cachePath != ""), func(t *testing.T) {
+				result, err := GetUpstreamLog(url, test.from, head)
+
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(result) != test.expected {
+					t.Fatalf("expected %d entries, got %d: %v", test.expected, len(result), result)
+				}
+
+				if expected := fmt.Sprintf("%s file%d", head[:8], len(hashes)-1); result[0] != expected {
+					t.Errorf("expected first entry %q, got %q", expected, result[0])
+				}
+			})
+		}
+	}
+
+	if _, err := os.Stat(GetMirrorPath(url)); err != nil {
+		t.Errorf("expected upstream log to use the mirror cache: %s", err)
+	}
+}
@@ -0,0 +1,85 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"strings"
+)
+
+func GetRemoteRevision(url string, refName string) (string, error) {
+	refMap, err := listRemoteRefs(url)
+
+	if err != nil {
+		return "", err
+	}
+
+	ref := refMap[refName+"^{}"]
+
+	if ref == nil {
+		ref = refMap[refName]
+	}
+
+	if ref != nil && ref.Type() == plumbing.SymbolicReference {
+		ref = refMap[ref.Target().String()]
+	}
+
+	if ref == nil || ref.Type() != plumbing.HashReference {
+		return "", errors.New(fmt.Sprintf("could not find ref %s in remote %s", refName, url))
+	}
+
+	return ref.Hash().String(), nil
+}
+
+func GetRemoteTags(url string) (map[string]string, error) {
+	refMap, err := listRemoteRefs(url)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]string{}
+
+	for name, ref := range refMap {
+		tag, found := strings.CutPrefix(name, "refs/tags/")
+
+		if !found || strings.HasSuffix(tag, "^{}") || ref.Type() != plumbing.HashReference {
+			continue
+		}
+
+		if peeled := refMap[name+"^{}"]; peeled != nil {
+			ref = peeled
+		}
+
+		result[tag] = ref.Hash().String()
+	}
+
+	return result, nil
+}
+
+func listRemoteRefs(url string) (map[string]*plumbing.Reference, error) {
+	remote := git.NewRemote(memory.NewStorage(), &gitconfig.RemoteConfig{
+		Name: "origin",
+		URLs: []string{url},
+	})
+
+	refs, err := remote.List(&git.ListOptions{
+		InsecureSkipTLS: insecureSkipTls,
+		PeelingOption:   git.AppendPeeled,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]*plumbing.Reference{}
+
+	for _, ref := range refs {
+		result[ref.Name().String()] = ref
+	}
+
+	return result, nil
+}
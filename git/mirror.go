@@ -0,0 +1,152 @@
+package git
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/ryanpetris/aur-builder/config"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	mirrorLastUsedFile = "aur-builder-last-used"
+)
+
+type Mirror struct {
+	Path     string
+	Url      string
+	Size     int64
+	LastUsed time.Time
+}
+
+func GetMirrorPath(url string) string {
+	hash := sha256.Sum256([]byte(url))
+	name := strings.TrimSuffix(path.Base(strings.TrimSuffix(url, "/")), ".git")
+
+	return filepath.Join(config.GetVcsMirrorsPath(), fmt.Sprintf("%s-%x.git", name, hash[:6]))
+}
+
+func UpdateMirror(url string) (string, error) {
+	mirrorPath := GetMirrorPath(url)
+
+	if _, err := os.Stat(mirrorPath); errors.Is(err, os.ErrNotExist) {
+		slog.Debug(fmt.Sprintf("Creating VCS mirror of %s", url))
+
+		_, err := git.PlainClone(mirrorPath, true, &git.CloneOptions{
+			URL:             url,
+			Mirror:          true,
+			InsecureSkipTLS: insecureSkipTls,
+		})
+
+		if err != nil {
+			_ = os.RemoveAll(mirrorPath)
+			return "", err
+		}
+	} else if err != nil {
+		return "", err
+	} else {
+		slog.Debug(fmt.Sprintf("Updating VCS mirror of %s", url))
+
+		repo, err := git.PlainOpen(mirrorPath)
+
+		if err != nil {
+			return "", err
+		}
+
+		err = repo.Fetch(&git.FetchOptions{
+			RemoteName:      "origin",
+			RefSpecs:        []gitconfig.RefSpec{"+refs/*:refs/*"},
+			Prune:           true,
+			Force:           true,
+			InsecureSkipTLS: insecureSkipTls,
+		})
+
+		if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+			return "", err
+		}
+	}
+
+	lastUsed := []byte(time.Now().UTC().Format(time.RFC3339))
+
+	if err := os.WriteFile(filepath.Join(mirrorPath, mirrorLastUsedFile), lastUsed, 0666); err != nil {
+		return "", err
+	}
+
+	return mirrorPath, nil
+}
+
+func GetMirrors() ([]*Mirror, error) {
+	mirrorsPath := config.GetVcsMirrorsPath()
+	entries, err := os.ReadDir(mirrorsPath)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var result []*Mirror
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		mirror := &Mirror{
+			Path: filepath.Join(mirrorsPath, entry.Name()),
+		}
+
+		if url, err := GetOriginUrl(mirror.Path); err == nil {
+			mirror.Url = url
+		}
+
+		if data, err := os.ReadFile(filepath.Join(mirror.Path, mirrorLastUsedFile)); err == nil {
+			mirror.LastUsed, _ = time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+		}
+
+		if mirror.Size, err = GetDirectorySize(mirror.Path); err != nil {
+			return nil, err
+		}
+
+		result = append(result, mirror)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+
+	return result, nil
+}
+
+func GetDirectorySize(dir string) (int64, error) {
+	var size int64
+
+	err := filepath.WalkDir(dir, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.Type().IsRegular() {
+			info, err := entry.Info()
+
+			if err != nil {
+				return err
+			}
+
+			size += info.Size()
+		}
+
+		return nil
+	})
+
+	return size, err
+}
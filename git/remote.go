@@ -2,6 +2,7 @@ package git
 
 import (
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
 )
 
 func GetOriginUrl(path string) (string, error) {
@@ -35,3 +36,22 @@ func GetRevision(path string) (string, error) {
 
 	return head.Hash().String(), nil
 }
+
+func CheckoutRevision(path string, revision string) error {
+	repo, err := git.PlainOpen(path)
+
+	if err != nil {
+		return err
+	}
+
+	worktree, err := repo.Worktree()
+
+	if err != nil {
+		return err
+	}
+
+	return worktree.Checkout(&git.CheckoutOptions{
+		Hash:  plumbing.NewHash(revision),
+		Force: true,
+	})
+}
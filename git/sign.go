@@ -0,0 +1,220 @@
+package git
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/ryanpetris/aur-builder/config"
+	"golang.org/x/crypto/ssh"
+	"io"
+	"time"
+)
+
+const sshSignatureNamespace = "git"
+
+type openpgpSigner struct {
+	key *openpgp.Entity
+}
+
+func (signer openpgpSigner) Sign(message io.Reader) ([]byte, error) {
+	buf := bytes.Buffer{}
+
+	if err := openpgp.ArmoredDetachSign(&buf, signer.key, message, nil); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+type sshSigner struct {
+	key ssh.Signer
+}
+
+func (signer sshSigner) Sign(message io.Reader) ([]byte, error) {
+	hash := sha512.New()
+
+	if _, err := io.Copy(hash, message); err != nil {
+		return nil, err
+	}
+
+	signedData := ssh.Marshal(struct {
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Hash          []byte
+	}{
+		Namespace:     sshSignatureNamespace,
+		HashAlgorithm: "sha512",
+		Hash:          hash.Sum(nil),
+	})
+
+	var signature *ssh.Signature
+	var err error
+
+	if algorithmSigner, ok := signer.key.(ssh.AlgorithmSigner); ok && signer.key.PublicKey().Type() == ssh.KeyAlgoRSA {
+		signature, err = algorithmSigner.SignWithAlgorithm(rand.Reader, append([]byte("SSHSIG"), signedData...), ssh.KeyAlgoRSASHA512)
+	} else {
+		signature, err = signer.key.Sign(rand.Reader, append([]byte("SSHSIG"), signedData...))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	blob := ssh.Marshal(struct {
+		Version       uint32
+		PublicKey     []byte
+		Namespace     string
+		Reserved      string
+		HashAlgorithm string
+		Signature     []byte
+	}{
+		Version:       1,
+		PublicKey:     signer.key.PublicKey().Marshal(),
+		Namespace:     sshSignatureNamespace,
+		HashAlgorithm: "sha512",
+		Signature:     ssh.Marshal(signature),
+	})
+
+	encoded := base64.StdEncoding.EncodeToString(append([]byte("SSHSIG"), blob...))
+	buf := bytes.Buffer{}
+	buf.WriteString("-----BEGIN SSH SIGNATURE-----\n")
+
+	for len(encoded) > 70 {
+		buf.WriteString(encoded[:70] + "\n")
+		encoded = encoded[70:]
+	}
+
+	buf.WriteString(encoded + "\n")
+	buf.WriteString("-----END SSH SIGNATURE-----\n")
+
+	return buf.Bytes(), nil
+}
+
+func setCommitSigningOptions(options *git.CommitOptions) error {
+	if name, email := config.GetCommitter(); name != "" || email != "" {
+		options.Committer = &object.Signature{
+			Name:  name,
+			Email: email,
+			When:  time.Now(),
+		}
+	}
+
+	key, err := config.GetSigningKey()
+
+	if err != nil || key == nil {
+		return err
+	}
+
+	passphrase := config.GetSigningPassphrase()
+
+	switch config.GetSigningFormat() {
+	case "openpgp":
+		entity, err := loadOpenpgpKey(key, passphrase)
+
+		if err != nil {
+			return err
+		}
+
+		options.SignKey = entity
+
+	case "ssh":
+		signer, err := loadSshKey(key, passphrase)
+
+		if err != nil {
+			return err
+		}
+
+		options.Signer = sshSigner{key: signer}
+
+	default:
+		return errors.New(fmt.Sprintf("invalid signing format: %s", config.GetSigningFormat()))
+	}
+
+	return nil
+}
+
+func loadOpenpgpKey(key []byte, passphrase []byte) (*openpgp.Entity, error) {
+	entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(key))
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entity := range entities {
+		if entity.PrivateKey == nil {
+			continue
+		}
+
+		if entity.PrivateKey.Encrypted {
+			if passphrase == nil {
+				return nil, errors.New("openpgp signing key is encrypted and no passphrase was given")
+			}
+
+			if err := entity.DecryptPrivateKeys(passphrase); err != nil {
+				return nil, err
+			}
+		}
+
+		return entity, nil
+	}
+
+	return nil, errors.New("no private key found in openpgp signing key")
+}
+
+func loadSshKey(key []byte, passphrase []byte) (ssh.Signer, error) {
+	signer, err := ssh.ParsePrivateKey(key)
+
+	var missingErr *ssh.PassphraseMissingError
+
+	if errors.As(err, &missingErr) {
+		if passphrase == nil {
+			return nil, errors.New("ssh signing key is encrypted and no passphrase was given")
+		}
+
+		return ssh.ParsePrivateKeyWithPassphrase(key, passphrase)
+	}
+
+	return signer, err
+}
+
+func signCommit(commit *object.Commit, options *git.CommitOptions) error {
+	signer := options.Signer
+
+	if signer == nil && options.SignKey != nil {
+		signer = openpgpSigner{key: options.SignKey}
+	}
+
+	if signer == nil {
+		return nil
+	}
+
+	encoded := &plumbing.MemoryObject{}
+
+	if err := commit.EncodeWithoutSignature(encoded); err != nil {
+		return err
+	}
+
+	reader, err := encoded.Reader()
+
+	if err != nil {
+		return err
+	}
+
+	signature, err := signer.Sign(reader)
+
+	if err != nil {
+		return err
+	}
+
+	commit.PGPSignature = string(signature)
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package git
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"golang.org/x/crypto/ssh"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignCommitSsh(t *testing.T) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not installed")
+	}
+
+	_, ed25519Key, err := ed25519.GenerateKey(rand.Reader)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		key  crypto.PrivateKey
+	}{
+		{"ed25519", ed25519Key},
+		{"rsa", rsaKey},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			signer, err := ssh.NewSignerFromKey(test.key)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			commit := newTestCommit()
+
+			if err := signCommit(commit, &git.CommitOptions{Signer: sshSigner{key: signer}}); err != nil {
+				t.Fatal(err)
+			}
+
+			dir := t.TempDir()
+			allowedSigners := path.Join(dir, "allowed_signers")
+			signature := path.Join(dir, "signature")
+			allowed := "test@example.com " + string(ssh.MarshalAuthorizedKey(signer.PublicKey()))
+
+			if err := os.WriteFile(allowedSigners, []byte(allowed), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := os.WriteFile(signature, []byte(commit.PGPSignature), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cmd := exec.Command("ssh-keygen", "-Y", "verify", "-f", allowedSigners, "-I", "test@example.com", "-n", "git", "-s", signature)
+			cmd.Stdin = bytes.NewReader(encodeTestCommit(t, commit))
+
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Fatalf("ssh-keygen could not verify signature: %s\n%s", err, out)
+			}
+
+			cmd = exec.Command("ssh-keygen", "-Y", "verify", "-f", allowedSigners, "-I", "test@example.com", "-n", "git", "-s", signature)
+			cmd.Stdin = strings.NewReader("tampered")
+
+			if err := cmd.Run(); err == nil {
+				t.Error("ssh-keygen verified a signature over different data")
+			}
+		})
+	}
+}
+
+func TestSignCommitOpenpgp(t *testing.T) {
+	entity, err := openpgp.NewEntity("test", "", "test@example.com", nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey := bytes.Buffer{}
+	writer, err := armor.Encode(&publicKey, openpgp.PublicKeyType, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := entity.Serialize(writer); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	commit := newTestCommit()
+
+	if err := signCommit(commit, &git.CommitOptions{SignKey: entity}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := commit.Verify(publicKey.String()); err != nil {
+		t.Fatalf("could not verify signature: %s", err)
+	}
+
+	commit.Message = "tampered\n"
+
+	if _, err := commit.Verify(publicKey.String()); err == nil {
+		t.Error("verified a signature over a different message")
+	}
+}
+
+func TestLoadSshKeyPassphrase(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block, err := ssh.MarshalPrivateKeyWithPassphrase(key, "", []byte("secret"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted := pem.EncodeToMemory(block)
+
+	if _, err := loadSshKey(encrypted, nil); err == nil {
+		t.Error("expected an error without a passphrase")
+	}
+
+	if _, err := loadSshKey(encrypted, []byte("wrong")); err == nil {
+		t.Error("expected an error with the wrong passphrase")
+	}
+
+	signer, err := loadSshKey(encrypted, []byte("secret"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := ssh.NewPublicKey(key.Public())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(signer.PublicKey().Marshal(), expected.Marshal()) {
+		t.Error("loaded key does not match the generated key")
+	}
+}
+
+func newTestCommit() *object.Commit {
+	signature := object.Signature{
+		Name:  "test",
+		Email: "test@example.com",
+		When:  time.Unix(1700000000, 0).UTC(),
+	}
+
+	return &object.Commit{
+		Author:    signature,
+		Committer: signature,
+		Message:   "Update foo to 1.0-1\n",
+		TreeHash:  plumbing.NewHash("4b825dc642cb6eb9a060e54bf8d69288fbee4904"),
+	}
+}
+
+func encodeTestCommit(t *testing.T, commit *object.Commit) []byte {
+	t.Helper()
+
+	encoded := &plumbing.MemoryObject{}
+
+	if err := commit.EncodeWithoutSignature(encoded); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := encoded.Reader()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := io.ReadAll(reader)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
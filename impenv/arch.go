@@ -3,6 +3,7 @@ package impenv
 import (
 	"github.com/ryanpetris/aur-builder/arch"
 	"github.com/ryanpetris/aur-builder/misc"
+	"time"
 )
 
 type ArchImportEnv struct {
@@ -39,3 +40,7 @@ func (ienv ArchImportEnv) PackageExists(pkgbase string) (bool, error) {
 func (ienv ArchImportEnv) PackageImport(pkgbase string, version string) error {
 	return arch.ClonePackage(pkgbase, version)
 }
+
+func (ienv ArchImportEnv) GetReleaseTime(pkginfo misc.PackageInfo) (time.Time, error) {
+	return arch.GetReleaseTime(pkginfo.Pkgbase, pkginfo.FullVersion)
+}
@@ -1,8 +1,11 @@
 package impenv
 
 import (
+	"errors"
+	"fmt"
 	"github.com/ryanpetris/aur-builder/aur"
 	"github.com/ryanpetris/aur-builder/misc"
+	"time"
 )
 
 type AurImportEnv struct {
@@ -26,6 +29,8 @@ func (ienv AurImportEnv) GetPackageInfo(pkgname []string) ([]misc.PackageInfo, e
 			Pkgbase:     item.PackageBase,
 			Pkgname:     item.Name,
 			FullVersion: item.Version,
+			Maintainer:  item.Maintainer,
+			ReleaseTime: time.Unix(item.LastModified, 0),
 		})
 	}
 
@@ -39,3 +44,11 @@ func (ienv AurImportEnv) PackageExists(pkgbase string) (bool, error) {
 func (ienv AurImportEnv) PackageImport(pkgbase string, version string) error {
 	return aur.ClonePackage(pkgbase, version)
 }
+
+func (ienv AurImportEnv) GetReleaseTime(pkginfo misc.PackageInfo) (time.Time, error) {
+	if pkginfo.ReleaseTime.Unix() <= 0 {
+		return time.Time{}, errors.New(fmt.Sprintf("no last modified time for package %s", pkginfo.Pkgbase))
+	}
+
+	return pkginfo.ReleaseTime, nil
+}
@@ -1,10 +1,14 @@
 package impenv
 
-import "github.com/ryanpetris/aur-builder/misc"
+import (
+	"github.com/ryanpetris/aur-builder/misc"
+	"time"
+)
 
 type ImportEnv interface {
 	IsLocalEnv() bool
 	GetPackageInfo(pkgname []string) ([]misc.PackageInfo, error)
 	PackageExists(pkgbase string) (bool, error)
 	PackageImport(pkgbase string, version string) error
+	GetReleaseTime(pkginfo misc.PackageInfo) (time.Time, error)
 }
@@ -12,6 +12,7 @@ import (
 	"path"
 	"slices"
 	"strings"
+	"time"
 )
 
 var (
@@ -144,3 +145,7 @@ func (ienv LocalImportEnv) getRemoteVersion(pkgbase string) (string, error) {
 
 	return ienv.cleanVersion(pkgbase, version)
 }
+
+func (ienv LocalImportEnv) GetReleaseTime(pkginfo misc.PackageInfo) (time.Time, error) {
+	return time.Time{}, errors.New("release times are not available for local packages")
+}
@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"github.com/ryanpetris/aur-builder/cli"
 	"github.com/ryanpetris/aur-builder/config"
+	"github.com/ryanpetris/aur-builder/notify"
 	"log/slog"
 	"os"
+	"path/filepath"
 )
 
 func main() {
@@ -15,16 +17,38 @@ func main() {
 	})))
 
 	cmdConfig := flag.String("config", "", "path to configuration file")
+	cmdCiEnv := flag.String("ci-env", "", "CI environment (auto, forgejo, github, gitlab, local, none)")
 	flag.Parse()
 
-	if *cmdConfig != "" {
-		cfg := config.GetGlobalConfig()
+	cfg := config.GetGlobalConfig()
+	cfgpath := *cmdConfig
 
-		if err := cfg.Load(*cmdConfig); err != nil {
+	if cfgpath == "" {
+		if found, err := config.FindConfigFile(); err != nil {
 			panic(err)
+		} else if found != "" {
+			cfgpath = found
+			cfg.SetRootDir(filepath.Dir(found))
 		}
 	}
 
+	if cfgpath != "" {
+		if err := cfg.Load(cfgpath); err != nil {
+			panic(err)
+		}
+	}
+
+	if err := cfg.LoadEnv(); err != nil {
+		panic(err)
+	}
+
+	if *cmdCiEnv != "" {
+		cfg.CiEnv = *cmdCiEnv
+		cfg.SetSource("ciEnv", "--ci-env")
+	}
+
+	defer notify.Flush()
+
 	args := flag.Args()
 
 	if len(args) < 1 {
@@ -54,6 +78,18 @@ func main() {
 	case "bump-pkgrel":
 		cli.BumpPkgrel(args)
 
+	case "vcs-cache":
+		cli.VcsCacheMain(args)
+
+	case "cleanup-branches":
+		cli.CleanupBranchesMain(args)
+
+	case "config":
+		cli.ConfigMain(args)
+
+	case "status":
+		cli.StatusMain(args)
+
 	default:
 		fmt.Println("invalid command")
 		os.Exit(1)
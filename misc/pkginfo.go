@@ -1,8 +1,12 @@
 package misc
 
+import "time"
+
 type PackageInfo struct {
 	Pkgbase     string
 	Pkgname     string
 	FullVersion string
+	Maintainer  string
+	ReleaseTime time.Time
 	BuildDeps   []string
 }
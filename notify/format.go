@@ -0,0 +1,62 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func formatPayload(format string, events []*Event) ([]byte, error) {
+	switch format {
+	case "generic":
+		return json.Marshal(map[string]any{
+			"source": "aur-builder",
+			"events": events,
+		})
+
+	case "slack":
+		return json.Marshal(map[string]any{
+			"text": formatText(events, func(event *Event) string {
+				message := slackEscaper.Replace(event.Message)
+
+				if event.Url == "" {
+					return message
+				}
+
+				return fmt.Sprintf("<%s|%s>", event.Url, message)
+			}),
+		})
+
+	case "matrix":
+		return json.Marshal(map[string]any{
+			"username": "aur-builder",
+			"text": formatText(events, func(event *Event) string {
+				if event.Url == "" {
+					return event.Message
+				}
+
+				return fmt.Sprintf("[%s](%s)", event.Message, event.Url)
+			}),
+		})
+
+	default:
+		return nil, errors.New(fmt.Sprintf("invalid webhook format: %s", format))
+	}
+}
+
+func formatText(events []*Event, formatEvent func(event *Event) string) string {
+	if len(events) == 1 {
+		return formatEvent(events[0])
+	}
+
+	lines := []string{fmt.Sprintf("aur-builder: %d notifications", len(events))}
+
+	for _, event := range events {
+		lines = append(lines, fmt.Sprintf("- %s", formatEvent(event)))
+	}
+
+	return strings.Join(lines, "\n")
+}
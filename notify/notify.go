@@ -0,0 +1,84 @@
+package notify
+
+import (
+	"fmt"
+	"github.com/ryanpetris/aur-builder/config"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	EventUpdate     = "update"
+	EventFailure    = "failure"
+	EventMaintainer = "maintainer"
+	EventNeedsBuild = "needs-build"
+)
+
+type Event struct {
+	Type       string    `json:"type"`
+	Pkgbase    string    `json:"pkgbase,omitempty"`
+	Version    string    `json:"version,omitempty"`
+	Url        string    `json:"url,omitempty"`
+	Repository string    `json:"repository,omitempty"`
+	Packages   []string  `json:"packages,omitempty"`
+	Message    string    `json:"message"`
+	Time       time.Time `json:"time"`
+}
+
+var pending []*Event
+var pendingMutex sync.Mutex
+
+func Notify(event *Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now().UTC()
+	}
+
+	if config.GetNotificationDigest() {
+		pendingMutex.Lock()
+		defer pendingMutex.Unlock()
+
+		pending = append(pending, event)
+		return
+	}
+
+	send([]*Event{event})
+}
+
+func Flush() {
+	pendingMutex.Lock()
+	events := pending
+	pending = nil
+	pendingMutex.Unlock()
+
+	if len(events) > 0 {
+		send(events)
+	}
+}
+
+func send(events []*Event) {
+	for _, webhook := range config.GetWebhooks() {
+		var filtered []*Event
+
+		for _, event := range events {
+			if webhook.WantsEvent(event.Type) {
+				filtered = append(filtered, event)
+			}
+		}
+
+		if len(filtered) == 0 {
+			continue
+		}
+
+		payload, err := formatPayload(webhook.GetFormat(), filtered)
+
+		if err != nil {
+			slog.Error(fmt.Sprintf("Could not format notification: %s", err))
+			continue
+		}
+
+		if err := deliver(webhook, payload); err != nil {
+			slog.Error(fmt.Sprintf("Could not deliver notification: %s", err))
+		}
+	}
+}
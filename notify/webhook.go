@@ -0,0 +1,82 @@
+package notify
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/ryanpetris/aur-builder/config"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var webhookClient = &http.Client{
+	Timeout: 30 * time.Second,
+}
+
+func deliver(webhook *config.WebhookConfig, payload []byte) error {
+	url, err := webhook.GetUrl()
+
+	if err != nil {
+		return err
+	}
+
+	delay, err := config.GetNotificationRetryDelay()
+
+	if err != nil {
+		return err
+	}
+
+	retries := config.GetNotificationRetries()
+
+	for attempt := 0; ; attempt++ {
+		retry, err := post(url, webhook.Headers, payload)
+
+		if err == nil {
+			return nil
+		}
+
+		if !retry || attempt >= retries {
+			return err
+		}
+
+		slog.Warn(fmt.Sprintf("Notification delivery failed, retrying in %s: %s", delay, err))
+
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+func post(url string, headers map[string]string, payload []byte) (bool, error) {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(payload))
+
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "aur-builder")
+
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := webhookClient.Do(req)
+
+	if err != nil {
+		return true, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+
+	return retry, errors.New(fmt.Sprintf("webhook returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body))))
+}
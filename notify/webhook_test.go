@@ -0,0 +1,196 @@
+package notify
+
+import (
+	"encoding/json"
+	"github.com/ryanpetris/aur-builder/config"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+type testReceiver struct {
+	*httptest.Server
+	mutex    sync.Mutex
+	payloads []map[string]any
+	headers  []http.Header
+	statuses []int
+}
+
+func newTestReceiver(t *testing.T, statuses ...int) *testReceiver {
+	receiver := &testReceiver{statuses: statuses}
+
+	receiver.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receiver.mutex.Lock()
+		defer receiver.mutex.Unlock()
+
+		body, err := io.ReadAll(r.Body)
+
+		if err != nil {
+			t.Error(err)
+		}
+
+		payload := map[string]any{}
+
+		if err := json.Unmarshal(body, &payload); err != nil {
+			t.Errorf("invalid payload %s: %s", body, err)
+		}
+
+		receiver.payloads = append(receiver.payloads, payload)
+		receiver.headers = append(receiver.headers, r.Header.Clone())
+
+		if len(receiver.statuses) > 0 {
+			status := receiver.statuses[0]
+			receiver.statuses = receiver.statuses[1:]
+			w.WriteHeader(status)
+		}
+	}))
+
+	t.Cleanup(receiver.Close)
+
+	return receiver
+}
+
+func (receiver *testReceiver) getPayloads() []map[string]any {
+	receiver.mutex.Lock()
+	defer receiver.mutex.Unlock()
+
+	return receiver.payloads
+}
+
+func setTestConfig(t *testing.T, digest bool, retries int, webhooks ...*config.WebhookConfig) {
+	cfg := config.GetGlobalConfig()
+	old := *cfg
+
+	cfg.Webhooks = webhooks
+	cfg.NotificationDigest = digest
+	cfg.NotificationRetries = &retries
+	cfg.NotificationRetryDelay = "1ms"
+
+	t.Cleanup(func() {
+		*cfg = old
+		pending = nil
+	})
+}
+
+func getTestEvents(payload map[string]any) []map[string]any {
+	var result []map[string]any
+
+	events, _ := payload["events"].([]any)
+
+	for _, event := range events {
+		result = append(result, event.(map[string]any))
+	}
+
+	return result
+}
+
+func TestNotifyDelivery(t *testing.T) {
+	all := newTestReceiver(t)
+	failures := newTestReceiver(t)
+	slack := newTestReceiver(t)
+
+	setTestConfig(t, false, 0,
+		&config.WebhookConfig{Url: all.URL, Headers: map[string]string{"Authorization": "Bearer token"}},
+		&config.WebhookConfig{Url: failures.URL, Events: []string{EventFailure}},
+		&config.WebhookConfig{Url: slack.URL, Format: "slack"},
+	)
+
+	Notify(&Event{Type: EventUpdate, Pkgbase: "foo", Version: "1.0-1", Url: "https://example.com/pulls/1", Message: "Update foo to 1.0-1"})
+	Notify(&Event{Type: EventFailure, Pkgbase: "bar", Message: "update failed for <bar> & co"})
+
+	payloads := all.getPayloads()
+
+	if len(payloads) != 2 {
+		t.Fatalf("expected 2 deliveries, got %d", len(payloads))
+	}
+
+	if header := all.headers[0]; header.Get("Authorization") != "Bearer token" || header.Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected headers %v", header)
+	}
+
+	events := getTestEvents(payloads[0])
+
+	if payloads[0]["source"] != "aur-builder" || len(events) != 1 || events[0]["type"] != EventUpdate || events[0]["pkgbase"] != "foo" || events[0]["url"] != "https://example.com/pulls/1" {
+		t.Errorf("unexpected payload %v", payloads[0])
+	}
+
+	if payloads := failures.getPayloads(); len(payloads) != 1 || getTestEvents(payloads[0])[0]["type"] != EventFailure {
+		t.Errorf("expected only the failure event, got %v", payloads)
+	}
+
+	expected := []string{"<https://example.com/pulls/1|Update foo to 1.0-1>", "update failed for &lt;bar&gt; &amp; co"}
+
+	if payloads := slack.getPayloads(); len(payloads) != 2 || payloads[0]["text"] != expected[0] || payloads[1]["text"] != expected[1] {
+		t.Errorf("expected slack messages %v, got %v", expected, payloads)
+	}
+}
+
+func TestNotifyRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		retries  int
+		statuses []int
+		attempts int
+	}{
+		{"success", 3, nil, 1},
+		{"server errors", 3, []int{500, 502}, 3},
+		{"rate limited", 3, []int{429}, 2},
+		{"client error", 3, []int{400}, 1},
+		{"exhausted", 2, []int{503, 503, 503, 503}, 3},
+		{"disabled", 0, []int{503}, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			receiver := newTestReceiver(t, test.statuses...)
+			setTestConfig(t, false, test.retries, &config.WebhookConfig{Url: receiver.URL})
+
+			Notify(&Event{Type: EventUpdate, Message: "Update foo to 1.0-1"})
+
+			if attempts := len(receiver.getPayloads()); attempts != test.attempts {
+				t.Errorf("expected %d attempts, got %d", test.attempts, attempts)
+			}
+		})
+	}
+}
+
+func TestNotifyDigest(t *testing.T) {
+	receiver := newTestReceiver(t)
+	matrix := newTestReceiver(t)
+
+	setTestConfig(t, true, 0,
+		&config.WebhookConfig{Url: receiver.URL},
+		&config.WebhookConfig{Url: matrix.URL, Format: "matrix", Events: []string{EventUpdate}},
+	)
+
+	Notify(&Event{Type: EventUpdate, Pkgbase: "foo", Url: "https://example.com/pulls/1", Message: "Update foo to 1.0-1"})
+	Notify(&Event{Type: EventUpdate, Pkgbase: "bar", Message: "Update bar to 2.0-1"})
+	Notify(&Event{Type: EventNeedsBuild, Packages: []string{"foo", "bar"}, Message: "2 packages need to be built"})
+
+	if payloads := receiver.getPayloads(); len(payloads) != 0 {
+		t.Fatalf("expected no deliveries before flush, got %v", payloads)
+	}
+
+	Flush()
+	Flush()
+
+	payloads := receiver.getPayloads()
+
+	if len(payloads) != 1 {
+		t.Fatalf("expected a single digest delivery, got %d", len(payloads))
+	}
+
+	events := getTestEvents(payloads[0])
+
+	if len(events) != 3 || events[0]["pkgbase"] != "foo" || events[1]["pkgbase"] != "bar" || events[2]["type"] != EventNeedsBuild {
+		t.Errorf("unexpected digest events %v", events)
+	}
+
+	expected := "aur-builder: 2 notifications\n- [Update foo to 1.0-1](https://example.com/pulls/1)\n- Update bar to 2.0-1"
+
+	if payloads := matrix.getPayloads(); len(payloads) != 1 || payloads[0]["text"] != expected {
+		t.Errorf("expected matrix digest %q, got %v", expected, payloads)
+	}
+}
@@ -0,0 +1,446 @@
+package pacman
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"github.com/ryanpetris/aur-builder/misc"
+	"io"
+	"log/slog"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type PkgbuildOptions struct {
+	AllowExec bool
+}
+
+type Pkgbuild struct {
+	Variables map[string]*PkgbuildVariable
+	Functions []string
+	Packages  map[string]map[string]*PkgbuildVariable
+}
+
+type PkgbuildVariable struct {
+	IsArray bool
+	Values  []string
+}
+
+var packageAttributeRegex = regexp.MustCompile("^(pkgdesc|url|install|changelog|arch|groups|license|depends|optdepends|provides|conflicts|replaces|options|backup)(_[A-Za-z0-9_]+)?$")
+
+type pkgbuildCacheKey struct {
+	Dir  string
+	Mode string
+	Hash [sha256.Size]byte
+}
+
+var pkgbuildCache = map[pkgbuildCacheKey]*Pkgbuild{}
+var pkgbuildCacheLock sync.Mutex
+
+func LoadPkgbuild(pkgbuildPath string) (*Pkgbuild, error) {
+	data, err := os.ReadFile(pkgbuildPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return LoadPkgbuildData(path.Dir(pkgbuildPath), data)
+}
+
+// LoadPkgbuildData evaluates a PKGBUILD without running external commands.
+// Results are cached by directory, sandbox mode and content, and each caller
+// gets its own copy.
+func LoadPkgbuildData(dir string, data []byte) (*Pkgbuild, error) {
+	dir, err := filepath.Abs(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	key := pkgbuildCacheKey{
+		Dir:  dir,
+		Mode: getSandboxMode(),
+		Hash: sha256.Sum256(data),
+	}
+
+	pkgbuildCacheLock.Lock()
+	cached := pkgbuildCache[key]
+	pkgbuildCacheLock.Unlock()
+
+	if cached != nil {
+		return cached.Clone(), nil
+	}
+
+	pkgbuild, err := EvalPkgbuild(dir, data, PkgbuildOptions{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	pkgbuildCacheLock.Lock()
+	pkgbuildCache[key] = pkgbuild
+	pkgbuildCacheLock.Unlock()
+
+	return pkgbuild.Clone(), nil
+}
+
+func EvalPkgbuild(dir string, data []byte, options PkgbuildOptions) (*Pkgbuild, error) {
+	sb, err := newSandbox(dir, getSandboxMode())
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer sb.Close()
+
+	runner, file, err := newPkgbuildRunner(sb, data, options, io.Discard)
+
+	if err != nil {
+		return nil, err
+	}
+
+	logSuspiciousPkgbuild(dir, file)
+
+	if err := runPkgbuild(runner, &syntax.File{}); err != nil {
+		return nil, err
+	}
+
+	baseline := map[string]expand.Variable{}
+
+	for name, variable := range runner.Vars {
+		baseline[name] = variable
+	}
+
+	if err := runPkgbuild(runner, file); err != nil {
+		return nil, err
+	}
+
+	result := &Pkgbuild{
+		Variables: map[string]*PkgbuildVariable{},
+		Packages:  map[string]map[string]*PkgbuildVariable{},
+	}
+
+	for name, variable := range runner.Vars {
+		if base, ok := baseline[name]; ok && base.String() == variable.String() {
+			continue
+		}
+
+		if pkgbuildVariable := getPkgbuildVariable(variable); pkgbuildVariable != nil {
+			result.Variables[name] = pkgbuildVariable
+		}
+	}
+
+	for name := range runner.Funcs {
+		result.Functions = append(result.Functions, name)
+	}
+
+	sort.Strings(result.Functions)
+
+	pkgnames := result.GetArray("pkgname")
+
+	for _, funcname := range result.Functions {
+		pkgname := ""
+
+		if funcname == "package" && len(pkgnames) > 0 {
+			pkgname = pkgnames[0]
+		} else if strings.HasPrefix(funcname, "package_") {
+			pkgname = strings.TrimPrefix(funcname, "package_")
+		}
+
+		if pkgname == "" {
+			continue
+		}
+
+		overrides := evalPackageOverrides(runner, runner.Funcs[funcname])
+
+		if len(overrides) > 0 {
+			result.Packages[pkgname] = overrides
+		}
+	}
+
+	return result, nil
+}
+
+func evalPackageOverrides(runner *interp.Runner, body *syntax.Stmt) map[string]*PkgbuildVariable {
+	var stmts []*syntax.Stmt
+	var names []string
+
+	syntax.Walk(body, func(node syntax.Node) bool {
+		switch node := node.(type) {
+		case *syntax.FuncDecl:
+			return false
+
+		case *syntax.Stmt:
+			call, ok := node.Cmd.(*syntax.CallExpr)
+
+			if !ok || len(call.Args) > 0 {
+				return true
+			}
+
+			for _, assign := range call.Assigns {
+				if assign.Name == nil || !packageAttributeRegex.MatchString(assign.Name.Value) {
+					return true
+				}
+			}
+
+			stmts = append(stmts, node)
+
+			for _, assign := range call.Assigns {
+				if !slices.Contains(names, assign.Name.Value) {
+					names = append(names, assign.Name.Value)
+				}
+			}
+
+			return false
+		}
+
+		return true
+	})
+
+	if len(stmts) == 0 {
+		return nil
+	}
+
+	subshell := runner.Subshell()
+
+	for _, stmt := range stmts {
+		_ = subshell.Run(context.Background(), stmt)
+	}
+
+	result := map[string]*PkgbuildVariable{}
+
+	for _, name := range names {
+		if variable := getPkgbuildVariable(subshell.Vars[name]); variable != nil {
+			result[name] = variable
+		}
+	}
+
+	return result
+}
+
+func getPkgbuildVariable(variable expand.Variable) *PkgbuildVariable {
+	if !variable.IsSet() {
+		return nil
+	}
+
+	switch variable.Kind {
+	case expand.String:
+		return &PkgbuildVariable{Values: []string{variable.Str}}
+
+	case expand.Indexed:
+		return &PkgbuildVariable{IsArray: true, Values: slices.Clone(variable.List)}
+	}
+
+	return nil
+}
+
+func RunPkgbuildFunction(pkgbuildPath string, funcname string, options PkgbuildOptions) (string, error) {
+	data, err := os.ReadFile(pkgbuildPath)
+
+	if err != nil {
+		return "", err
+	}
+
+	dir := path.Dir(pkgbuildPath)
+	stdoutBuf := &bytes.Buffer{}
+
+	sb, err := newSandbox(dir, getSandboxMode())
+
+	if err != nil {
+		return "", err
+	}
+
+	defer sb.Close()
+
+	runner, file, err := newPkgbuildRunner(sb, data, options, stdoutBuf)
+
+	if err != nil {
+		return "", err
+	}
+
+	if err := runPkgbuild(runner, file); err != nil {
+		return "", err
+	}
+
+	if _, ok := runner.Funcs[funcname]; !ok {
+		return "", errors.New(fmt.Sprintf("function %s not defined in %s", funcname, pkgbuildPath))
+	}
+
+	stdoutBuf.Reset()
+
+	call, err := syntax.NewParser().Parse(strings.NewReader(fmt.Sprintf("srcdir=%q\n%s\n", path.Join(dir, "src"), funcname)), funcname)
+
+	if err != nil {
+		return "", err
+	}
+
+	if err := runner.Run(context.Background(), call); err != nil {
+		return "", err
+	}
+
+	return stdoutBuf.String(), nil
+}
+
+func newPkgbuildRunner(sb *sandbox, data []byte, options PkgbuildOptions, stdout io.Writer) (*interp.Runner, *syntax.File, error) {
+	parser := syntax.NewParser(syntax.Variant(syntax.LangBash))
+	file, err := parser.Parse(bytes.NewReader(data), "PKGBUILD")
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	execHandler := denyExecHandler
+
+	if options.AllowExec {
+		execHandler = sb.execHandler
+	}
+
+	runner, err := interp.New(
+		interp.Dir(sb.Dir),
+		interp.Env(sb.environ()),
+		interp.StdIO(nil, stdout, io.Discard),
+		interp.OpenHandler(sb.openHandler),
+		interp.ExecHandlers(func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+			return execHandler
+		}),
+	)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return runner, file, nil
+}
+
+func runPkgbuild(runner *interp.Runner, file *syntax.File) error {
+	if err := runner.Run(context.Background(), file); err != nil {
+		if _, ok := interp.IsExitStatus(err); !ok {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func denyExecHandler(ctx context.Context, args []string) error {
+	slog.Debug(fmt.Sprintf("Refusing to run external command while evaluating PKGBUILD: %s", strings.Join(args, " ")))
+
+	return interp.NewExitStatus(127)
+}
+
+func (pkgbuild *Pkgbuild) Clone() *Pkgbuild {
+	result := &Pkgbuild{
+		Variables: cloneVariables(pkgbuild.Variables),
+		Functions: slices.Clone(pkgbuild.Functions),
+		Packages:  map[string]map[string]*PkgbuildVariable{},
+	}
+
+	for pkgname, overrides := range pkgbuild.Packages {
+		result.Packages[pkgname] = cloneVariables(overrides)
+	}
+
+	return result
+}
+
+func cloneVariables(variables map[string]*PkgbuildVariable) map[string]*PkgbuildVariable {
+	result := map[string]*PkgbuildVariable{}
+
+	for name, variable := range variables {
+		result[name] = &PkgbuildVariable{IsArray: variable.IsArray, Values: slices.Clone(variable.Values)}
+	}
+
+	return result
+}
+
+func (pkgbuild *Pkgbuild) HasFunction(name string) bool {
+	return slices.Contains(pkgbuild.Functions, name)
+}
+
+func (pkgbuild *Pkgbuild) GetVar(name string) string {
+	if variable := pkgbuild.Variables[name]; variable != nil && len(variable.Values) > 0 {
+		return variable.Values[0]
+	}
+
+	return ""
+}
+
+func (pkgbuild *Pkgbuild) GetArray(name string) []string {
+	if variable := pkgbuild.Variables[name]; variable != nil {
+		return variable.Values
+	}
+
+	return nil
+}
+
+func (pkgbuild *Pkgbuild) GetArchArrays(name string) map[string][]string {
+	result := map[string][]string{}
+
+	for varname, variable := range pkgbuild.Variables {
+		if varname == name {
+			result[""] = variable.Values
+		} else if strings.HasPrefix(varname, name+"_") {
+			result[strings.TrimPrefix(varname, name+"_")] = variable.Values
+		}
+	}
+
+	return result
+}
+
+func (pkgbuild *Pkgbuild) GetPkgInfo() *PkgInfo {
+	pkginfo := &PkgInfo{}
+
+	var names []string
+
+	for name := range pkgbuild.Variables {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		field, arch := name, ""
+
+		if strings.Contains(field, "_") {
+			parts := strings.SplitN(field, "_", 2)
+			field, arch = parts[0], parts[1]
+		}
+
+		for _, value := range pkgbuild.Variables[name].Values {
+			if value == "" {
+				continue
+			}
+
+			pkginfo.addValue(field, arch, value)
+		}
+	}
+
+	if pkginfo.Pkgbase == "" && len(pkginfo.Pkgname) > 0 {
+		pkginfo.Pkgbase = pkginfo.Pkgname[0]
+	}
+
+	for _, pkgname := range pkginfo.Pkgname {
+		pkg := &PkgInfoPackage{
+			Pkgname:   pkgname,
+			Overrides: map[string][]string{},
+		}
+
+		for name, variable := range pkgbuild.Packages[pkgname] {
+			pkg.Overrides[name] = misc.FilterEmptyString(variable.Values)
+		}
+
+		pkginfo.Packages = append(pkginfo.Packages, pkg)
+	}
+
+	return pkginfo
+}
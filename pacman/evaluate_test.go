@@ -0,0 +1,122 @@
+package pacman
+
+import (
+	"crypto/sha256"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"testing"
+)
+
+func writeTestPkgbuild(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		if err := os.WriteFile(path.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadPkgbuildDataCache(t *testing.T) {
+	data := []byte("pkgname=foo\nsource ./version.sh\npkgrel=1\n")
+	first := t.TempDir()
+	second := t.TempDir()
+
+	writeTestPkgbuild(t, first, map[string]string{"version.sh": "pkgver=1.0\n"})
+	writeTestPkgbuild(t, second, map[string]string{"version.sh": "pkgver=2.0\n"})
+
+	tests := []struct {
+		dir      string
+		expected string
+	}{
+		{first, "1.0"},
+		{second, "2.0"},
+		{first, "1.0"},
+	}
+
+	for _, test := range tests {
+		pkgbuild, err := LoadPkgbuildData(test.dir, data)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if pkgver := pkgbuild.GetVar("pkgver"); pkgver != test.expected {
+			t.Errorf("expected pkgver %s in %s, got %s", test.expected, test.dir, pkgver)
+		}
+	}
+
+	for _, dir := range []string{first, second} {
+		key := pkgbuildCacheKey{Dir: dir, Mode: getSandboxMode(), Hash: sha256.Sum256(data)}
+
+		pkgbuildCacheLock.Lock()
+		cached := pkgbuildCache[key]
+		pkgbuildCacheLock.Unlock()
+
+		if cached == nil {
+			t.Errorf("expected a cache entry for %s", dir)
+		}
+	}
+
+	pkgbuild, err := LoadPkgbuildData(first, data)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkgbuild.Variables["pkgver"].Values[0] = "mutated"
+	pkgbuild.Variables["pkgrel"] = nil
+	pkgbuild.Functions = append(pkgbuild.Functions, "mutated")
+
+	pkgbuild, err = LoadPkgbuildData(first, data)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pkgbuild.GetVar("pkgver") != "1.0" || pkgbuild.GetVar("pkgrel") != "1" || pkgbuild.HasFunction("mutated") {
+		t.Errorf("cached PKGBUILD was changed through a previous result: %+v", pkgbuild.Variables)
+	}
+}
+
+func TestEvalPkgbuildExec(t *testing.T) {
+	if _, err := exec.LookPath("expr"); err != nil {
+		t.Skip("expr not installed")
+	}
+
+	dir := t.TempDir()
+
+	writeTestPkgbuild(t, dir, map[string]string{
+		"PKGBUILD": "pkgname=foo\npkgver=1.0\npkgrel=1\n_computed=$(expr 1 + 2)\npkgver() {\n  expr 1 + 2\n}\n",
+	})
+
+	pkgbuild, err := LoadPkgbuild(path.Join(dir, "PKGBUILD"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if computed := pkgbuild.GetVar("_computed"); computed != "" {
+		t.Errorf("expected top-level command substitution to be refused, got %q", computed)
+	}
+
+	if !pkgbuild.HasFunction("pkgver") {
+		t.Error("expected pkgver function")
+	}
+
+	if out, err := RunPkgbuildFunction(path.Join(dir, "PKGBUILD"), "pkgver", PkgbuildOptions{}); err == nil {
+		t.Errorf("expected pkgver to be refused without exec, got %q", out)
+	}
+
+	out, err := RunPkgbuildFunction(path.Join(dir, "PKGBUILD"), "pkgver", PkgbuildOptions{AllowExec: true})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.TrimSpace(out) != "3" {
+		t.Errorf("expected pkgver to run with exec allowed, got %q", out)
+	}
+}
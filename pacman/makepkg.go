@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/ryanpetris/aur-builder/config"
 	"log/slog"
+	"os"
 	"os/exec"
 )
 
@@ -19,6 +20,10 @@ func DownloadSources(pkgbase string) error {
 	cmd.Stdout = outBuf
 	cmd.Stderr = outBuf
 
+	if config.GetVcsCachePath() != "" {
+		cmd.Env = append(os.Environ(), fmt.Sprintf("SRCDEST=%s", config.GetVcsSrcdestPath(pkgbase)))
+	}
+
 	err := cmd.Run()
 
 	if err != nil {
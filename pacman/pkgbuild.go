@@ -1,40 +1,20 @@
 package pacman
 
 import (
-	"bytes"
-	"fmt"
 	"github.com/ryanpetris/aur-builder/config"
-	"os/exec"
+	"path"
 	"strings"
 )
 
 func GetPkgbuildVar(pkgbase string, varname string) (string, error) {
-	cmdText := `
-set -e
-set -o pipefail
-
-PKGBUILD_VAR="${PKGBUILD_VAR}[*]"
-
-pushd "$(dirname "$PKGBUILD_PATH")" >/dev/null
-source "$(basename "$PKGBUILD_PATH")"
-export IFS=$'\n'
-echo "${!PKGBUILD_VAR}"
-`
-
 	mergedPath := config.GetMergedPath(pkgbase)
+	pkgbuild, err := LoadPkgbuild(path.Join(mergedPath, "PKGBUILD"))
 
-	var stdoutBuf bytes.Buffer
-
-	cmd := exec.Command("bash", "-c", cmdText)
-	cmd.Stdout = &stdoutBuf
-	cmd.Env = append(cmd.Environ(), fmt.Sprintf("PKGBUILD_PATH=%s/PKGBUILD", mergedPath))
-	cmd.Env = append(cmd.Environ(), fmt.Sprintf("PKGBUILD_VAR=%s", varname))
-
-	if err := cmd.Run(); err != nil {
+	if err != nil {
 		return "", err
 	}
 
-	return stdoutBuf.String(), nil
+	return strings.Join(pkgbuild.GetArray(varname), "\n"), nil
 }
 
 func GetPkgbuildVars(pkgbase string, varname string) ([]string, error) {
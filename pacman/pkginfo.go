@@ -1,10 +1,9 @@
 package pacman
 
 import (
-	"bytes"
 	"fmt"
 	"github.com/ryanpetris/aur-builder/config"
-	"os/exec"
+	"path"
 	"regexp"
 	"slices"
 	"strconv"
@@ -15,7 +14,7 @@ type PkgInfo struct {
 	Pkgbase   string
 	Pkgname   []string
 	Pkgver    string
-	Pkgrel    int
+	Pkgrel    string
 	Epoch     int
 	Pkgdesc   string
 	Url       string
@@ -46,6 +45,8 @@ type PkgInfo struct {
 	Sha384Sums   []PkgInfoArchItem
 	Sha512Sums   []PkgInfoArchItem
 	B2Sums       []PkgInfoArchItem
+
+	Packages []*PkgInfoPackage
 }
 
 type PkgInfoArchItem struct {
@@ -53,6 +54,11 @@ type PkgInfoArchItem struct {
 	Value string
 }
 
+type PkgInfoPackage struct {
+	Pkgname   string
+	Overrides map[string][]string
+}
+
 func (pkginfo *PkgInfo) GetAllBuildDepends(arch ...string) []string {
 	var allDeps []PkgInfoArchItem
 	var result []string
@@ -78,10 +84,20 @@ func (pkginfo *PkgInfo) GetAllBuildDepends(arch ...string) []string {
 
 func (pkginfo *PkgInfo) GetFullVersion() string {
 	if pkginfo.Epoch > 0 {
-		return fmt.Sprintf("%d:%s-%d", pkginfo.Epoch, pkginfo.Pkgver, pkginfo.Pkgrel)
+		return fmt.Sprintf("%d:%s-%s", pkginfo.Epoch, pkginfo.Pkgver, pkginfo.Pkgrel)
+	}
+
+	return fmt.Sprintf("%s-%s", pkginfo.Pkgver, pkginfo.Pkgrel)
+}
+
+func (pkginfo *PkgInfo) GetPackage(pkgname string) *PkgInfoPackage {
+	for _, pkg := range pkginfo.Packages {
+		if pkg.Pkgname == pkgname {
+			return pkg
+		}
 	}
 
-	return fmt.Sprintf("%s-%d", pkginfo.Pkgver, pkginfo.Pkgrel)
+	return nil
 }
 
 func (pkginfo *PkgInfo) Load(lines []string) error {
@@ -108,112 +124,88 @@ func (pkginfo *PkgInfo) Load(lines []string) error {
 			continue
 		}
 
-		switch field {
-		case "pkgbase":
-			pkginfo.Pkgbase = line
-		case "pkgname":
-			pkginfo.Pkgname = append(pkginfo.Pkgname, line)
-		case "pkgver":
-			pkginfo.Pkgver = line
-		case "pkgrel":
-			pkginfo.Pkgrel, _ = strconv.Atoi(line)
-		case "epoch":
-			pkginfo.Epoch, _ = strconv.Atoi(line)
-		case "pkgdesc":
-			pkginfo.Pkgdesc = line
-		case "url":
-			pkginfo.Url = line
-		case "install":
-			pkginfo.Install = line
-		case "changelog":
-			pkginfo.Changelog = line
-		case "arch":
-			pkginfo.Arch = append(pkginfo.Arch, line)
-		case "groups":
-			pkginfo.Groups = append(pkginfo.Groups, line)
-		case "license":
-			pkginfo.License = append(pkginfo.License, line)
-		case "noextract":
-			pkginfo.NoExtract = append(pkginfo.NoExtract, line)
-		case "options":
-			pkginfo.Options = append(pkginfo.Options, line)
-		case "backup":
-			pkginfo.Backup = append(pkginfo.Backup, line)
-		case "validpgpkeys":
-			pkginfo.ValidPgpKeys = append(pkginfo.ValidPgpKeys, line)
-		case "source":
-			pkginfo.Source = append(pkginfo.Source, PkgInfoArchItem{arch, line})
-		case "depends":
-			pkginfo.Depends = append(pkginfo.Depends, PkgInfoArchItem{arch, line})
-		case "checkdepends":
-			pkginfo.CheckDepends = append(pkginfo.CheckDepends, PkgInfoArchItem{arch, line})
-		case "makedepends":
-			pkginfo.MakeDepends = append(pkginfo.MakeDepends, PkgInfoArchItem{arch, line})
-		case "optdepends":
-			pkginfo.OptDepends = append(pkginfo.OptDepends, PkgInfoArchItem{arch, line})
-		case "provides":
-			pkginfo.Provides = append(pkginfo.Provides, PkgInfoArchItem{arch, line})
-		case "conflicts":
-			pkginfo.Conflicts = append(pkginfo.Conflicts, PkgInfoArchItem{arch, line})
-		case "replaces":
-			pkginfo.Replaces = append(pkginfo.Replaces, PkgInfoArchItem{arch, line})
-		case "cksums":
-			pkginfo.CkSums = append(pkginfo.CkSums, PkgInfoArchItem{arch, line})
-		case "md5sums":
-			pkginfo.Md5Sums = append(pkginfo.Md5Sums, PkgInfoArchItem{arch, line})
-		case "sha1sums":
-			pkginfo.Sha1Sums = append(pkginfo.Sha1Sums, PkgInfoArchItem{arch, line})
-		case "sha224sums":
-			pkginfo.Sha224Sums = append(pkginfo.Sha224Sums, PkgInfoArchItem{arch, line})
-		case "sha256sums":
-			pkginfo.Sha256Sums = append(pkginfo.Sha256Sums, PkgInfoArchItem{arch, line})
-		case "sha384sums":
-			pkginfo.Sha384Sums = append(pkginfo.Sha384Sums, PkgInfoArchItem{arch, line})
-		case "sha512sums":
-			pkginfo.Sha512Sums = append(pkginfo.Sha512Sums, PkgInfoArchItem{arch, line})
-		case "b2sums":
-			pkginfo.B2Sums = append(pkginfo.B2Sums, PkgInfoArchItem{arch, line})
-		}
+		pkginfo.addValue(field, arch, line)
 	}
 
 	return nil
 }
 
-func LoadPkgInfo(pkgbase string) (*PkgInfo, error) {
-	cmdText := `
-OLDENVARS=()
-NEWENVARS=()
-PKGENVARS=()
-
-mapfile -t OLDENVARS < <(compgen -v)
-source "${PKGBUILD_PATH}"
-mapfile -t NEWENVARS < <(compgen -v)
-mapfile -t PKGENVARS < <(comm -13 <(IFS=$'\n'; echo "${OLDENVARS[*]}" | sort) <(IFS=$'\n'; echo "${NEWENVARS[*]}" | sort))
-
-for PKGENVAR in "${PKGENVARS[@]}"; do
-	echo "%${PKGENVAR}%"
-	(IFS=$'\n'; eval echo '"'"\${${PKGENVAR}[*]}"'"')
-	echo ""
-done
-`
-
-	mergedPath := config.GetMergedPath(pkgbase)
-
-	var stdoutBuf bytes.Buffer
-
-	cmd := exec.Command("bash", "-c", cmdText)
-	cmd.Stdout = &stdoutBuf
-	cmd.Env = append(cmd.Environ(), fmt.Sprintf("PKGBUILD_PATH=%s/PKGBUILD", mergedPath))
-
-	if err := cmd.Run(); err != nil {
-		return nil, err
+func (pkginfo *PkgInfo) addValue(field string, arch string, value string) {
+	switch field {
+	case "pkgbase":
+		pkginfo.Pkgbase = value
+	case "pkgname":
+		pkginfo.Pkgname = append(pkginfo.Pkgname, value)
+	case "pkgver":
+		pkginfo.Pkgver = value
+	case "pkgrel":
+		pkginfo.Pkgrel = value
+	case "epoch":
+		pkginfo.Epoch, _ = strconv.Atoi(value)
+	case "pkgdesc":
+		pkginfo.Pkgdesc = value
+	case "url":
+		pkginfo.Url = value
+	case "install":
+		pkginfo.Install = value
+	case "changelog":
+		pkginfo.Changelog = value
+	case "arch":
+		pkginfo.Arch = append(pkginfo.Arch, value)
+	case "groups":
+		pkginfo.Groups = append(pkginfo.Groups, value)
+	case "license":
+		pkginfo.License = append(pkginfo.License, value)
+	case "noextract":
+		pkginfo.NoExtract = append(pkginfo.NoExtract, value)
+	case "options":
+		pkginfo.Options = append(pkginfo.Options, value)
+	case "backup":
+		pkginfo.Backup = append(pkginfo.Backup, value)
+	case "validpgpkeys":
+		pkginfo.ValidPgpKeys = append(pkginfo.ValidPgpKeys, value)
+	case "source":
+		pkginfo.Source = append(pkginfo.Source, PkgInfoArchItem{arch, value})
+	case "depends":
+		pkginfo.Depends = append(pkginfo.Depends, PkgInfoArchItem{arch, value})
+	case "checkdepends":
+		pkginfo.CheckDepends = append(pkginfo.CheckDepends, PkgInfoArchItem{arch, value})
+	case "makedepends":
+		pkginfo.MakeDepends = append(pkginfo.MakeDepends, PkgInfoArchItem{arch, value})
+	case "optdepends":
+		pkginfo.OptDepends = append(pkginfo.OptDepends, PkgInfoArchItem{arch, value})
+	case "provides":
+		pkginfo.Provides = append(pkginfo.Provides, PkgInfoArchItem{arch, value})
+	case "conflicts":
+		pkginfo.Conflicts = append(pkginfo.Conflicts, PkgInfoArchItem{arch, value})
+	case "replaces":
+		pkginfo.Replaces = append(pkginfo.Replaces, PkgInfoArchItem{arch, value})
+	case "cksums":
+		pkginfo.CkSums = append(pkginfo.CkSums, PkgInfoArchItem{arch, value})
+	case "md5sums":
+		pkginfo.Md5Sums = append(pkginfo.Md5Sums, PkgInfoArchItem{arch, value})
+	case "sha1sums":
+		pkginfo.Sha1Sums = append(pkginfo.Sha1Sums, PkgInfoArchItem{arch, value})
+	case "sha224sums":
+		pkginfo.Sha224Sums = append(pkginfo.Sha224Sums, PkgInfoArchItem{arch, value})
+	case "sha256sums":
+		pkginfo.Sha256Sums = append(pkginfo.Sha256Sums, PkgInfoArchItem{arch, value})
+	case "sha384sums":
+		pkginfo.Sha384Sums = append(pkginfo.Sha384Sums, PkgInfoArchItem{arch, value})
+	case "sha512sums":
+		pkginfo.Sha512Sums = append(pkginfo.Sha512Sums, PkgInfoArchItem{arch, value})
+	case "b2sums":
+		pkginfo.B2Sums = append(pkginfo.B2Sums, PkgInfoArchItem{arch, value})
 	}
+}
 
-	pkginfo := &PkgInfo{}
+func LoadPkgInfo(pkgbase string) (*PkgInfo, error) {
+	mergedPath := config.GetMergedPath(pkgbase)
+	pkgbuild, err := LoadPkgbuild(path.Join(mergedPath, "PKGBUILD"))
 
-	if err := pkginfo.Load(strings.Split(stdoutBuf.String(), "\n")); err != nil {
+	if err != nil {
 		return nil, err
 	}
 
-	return pkginfo, nil
+	return pkgbuild.GetPkgInfo(), nil
 }
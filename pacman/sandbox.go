@@ -0,0 +1,402 @@
+package pacman
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ryanpetris/aur-builder/config"
+	"io"
+	"log/slog"
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+const (
+	SandboxAuto       = "auto"
+	SandboxBwrap      = "bwrap"
+	SandboxUnshare    = "unshare"
+	SandboxRestricted = "restricted"
+)
+
+var (
+	sandboxPath = "/usr/local/sbin:/usr/local/bin:/usr/bin:/bin"
+
+	sandboxRestrictedCommands = []string{
+		"basename", "cat", "cut", "date", "dirname", "expr", "grep", "head", "printf", "tail", "tr", "wc",
+	}
+
+	sandboxSystemDirs = []string{"bin", "sbin", "lib", "lib32", "lib64"}
+
+	sandboxSystemFiles = []string{"/etc/makepkg.conf"}
+
+	sandboxDevices = []string{"/dev/null", "/dev/zero", "/dev/random", "/dev/urandom"}
+
+	sandboxUnshareScript = `set -e
+root="$1"; dir="$2"; home="$3"; cwd="$4"; shift 4
+path="$PATH"
+PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin
+bind() {
+	mount --bind "$1" "$root$1"
+	[ "$2" = rw ] || mount -o remount,bind,ro "$root$1"
+}
+mount -t tmpfs -o mode=0755 tmpfs "$root"
+mkdir "$root/usr" "$root/etc" "$root/dev" "$root/proc" "$root/tmp"
+mount -t tmpfs tmpfs "$root/tmp"
+mkdir -p "$root$dir" "$root$home"
+bind /usr
+for name in ` + strings.Join(sandboxSystemDirs, " ") + `; do
+	if [ -L "/$name" ]; then
+		ln -s "$(readlink "/$name")" "$root/$name"
+	elif [ -d "/$name" ]; then
+		mkdir "$root/$name"
+		bind "/$name"
+	fi
+done
+for file in ` + strings.Join(sandboxSystemFiles, " ") + `; do
+	[ -f "$file" ] || continue
+	touch "$root$file"
+	bind "$file"
+done
+for device in ` + strings.Join(sandboxDevices, " ") + `; do
+	[ -e "$device" ] || continue
+	touch "$root$device"
+	mount --bind "$device" "$root$device"
+done
+bind "$dir"
+bind "$home" rw
+mount -t proc proc "$root/proc"
+mount -o remount,ro "$root"
+chroot=$(command -v chroot)
+PATH="$path"
+cd /
+exec "$chroot" "$root" /bin/sh -c 'cd "$1" && shift && exec "$@"' sh "$cwd" "$@"`
+)
+
+var sandboxMode string
+var sandboxModeOnce sync.Once
+
+type sandbox struct {
+	Mode string
+	Dir  string
+	Home string
+	Root string
+}
+
+func getSandboxMode() string {
+	sandboxModeOnce.Do(func() {
+		sandboxMode = config.GetSandbox()
+
+		if sandboxMode != SandboxAuto {
+			return
+		}
+
+		if _, err := exec.LookPath("bwrap"); err == nil {
+			sandboxMode = SandboxBwrap
+		} else if isUnshareAvailable() {
+			sandboxMode = SandboxUnshare
+		} else {
+			sandboxMode = SandboxRestricted
+		}
+
+		slog.Debug(fmt.Sprintf("Using %s sandbox for PKGBUILD evaluation", sandboxMode))
+	})
+
+	return sandboxMode
+}
+
+func isUnshareAvailable() bool {
+	if _, err := exec.LookPath("unshare"); err != nil {
+		return false
+	}
+
+	dir, err := os.MkdirTemp("", "aur-builder-probe-")
+
+	if err != nil {
+		return false
+	}
+
+	defer os.RemoveAll(dir)
+
+	sb, err := newSandbox(dir, SandboxUnshare)
+
+	if err != nil {
+		return false
+	}
+
+	defer sb.Close()
+
+	return exec.Command("unshare", sb.getUnshareArgs(sb.Home, []string{"true"})...).Run() == nil
+}
+
+func (sb *sandbox) getUnshareArgs(cwd string, command []string) []string {
+	args := []string{"--net", "--map-root-user", "--mount", "--pid", "--fork", "--", "/bin/sh", "-c", sandboxUnshareScript, "sh", sb.Root, sb.Dir, sb.Home, cwd}
+
+	return append(args, command...)
+}
+
+func getBwrapSystemArgs() []string {
+	args := []string{"--ro-bind", "/usr", "/usr"}
+
+	for _, name := range sandboxSystemDirs {
+		systemPath := "/" + name
+
+		if target, err := os.Readlink(systemPath); err == nil {
+			args = append(args, "--symlink", target, systemPath)
+		} else if info, err := os.Stat(systemPath); err == nil && info.IsDir() {
+			args = append(args, "--ro-bind", systemPath, systemPath)
+		}
+	}
+
+	for _, file := range sandboxSystemFiles {
+		args = append(args, "--ro-bind-try", file, file)
+	}
+
+	return args
+}
+
+func newSandbox(dir string, mode string) (*sandbox, error) {
+	dir, err := filepath.Abs(dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sb := &sandbox{
+		Mode: mode,
+		Dir:  dir,
+	}
+
+	if sb.Home, err = os.MkdirTemp("", "aur-builder-home-"); err != nil {
+		return nil, err
+	}
+
+	if mode == SandboxUnshare {
+		if sb.Root, err = os.MkdirTemp("", "aur-builder-root-"); err != nil {
+			_ = sb.Close()
+			return nil, err
+		}
+	}
+
+	return sb, nil
+}
+
+func (sb *sandbox) Close() error {
+	if sb.Root != "" {
+		if err := os.RemoveAll(sb.Root); err != nil {
+			return err
+		}
+	}
+
+	return os.RemoveAll(sb.Home)
+}
+
+func (sb *sandbox) environ() expand.Environ {
+	return expand.ListEnviron(
+		fmt.Sprintf("HOME=%s", sb.Home),
+		fmt.Sprintf("PATH=%s", sandboxPath),
+		fmt.Sprintf("TMPDIR=%s", sb.Home),
+		"LANG=C.UTF-8",
+		"LC_ALL=C.UTF-8",
+		"GIT_CONFIG_NOSYSTEM=1",
+		"GIT_TERMINAL_PROMPT=0",
+	)
+}
+
+func (sb *sandbox) execHandler(ctx context.Context, args []string) error {
+	hc := interp.HandlerCtx(ctx)
+	name := filepath.Base(args[0])
+
+	commandPath, err := interp.LookPathDir(hc.Dir, hc.Env, args[0])
+
+	if err != nil {
+		fmt.Fprintf(hc.Stderr, "%s: command not found\n", args[0])
+		return interp.NewExitStatus(127)
+	}
+
+	var env []string
+
+	hc.Env.Each(func(name string, variable expand.Variable) bool {
+		if variable.Exported && variable.IsSet() {
+			env = append(env, fmt.Sprintf("%s=%s", name, variable.String()))
+		}
+
+		return true
+	})
+
+	var cmd *exec.Cmd
+
+	switch sb.Mode {
+	case SandboxBwrap:
+		bwrapArgs := []string{
+			"--unshare-all",
+			"--die-with-parent",
+		}
+
+		bwrapArgs = append(bwrapArgs, getBwrapSystemArgs()...)
+		bwrapArgs = append(bwrapArgs,
+			"--dev", "/dev",
+			"--proc", "/proc",
+			"--tmpfs", "/tmp",
+			"--ro-bind", sb.Dir, sb.Dir,
+			"--bind", sb.Home, sb.Home,
+			"--chdir", hc.Dir,
+			"--",
+			commandPath,
+		)
+
+		cmd = exec.CommandContext(ctx, "bwrap", append(bwrapArgs, args[1:]...)...)
+
+	case SandboxUnshare:
+		cmd = exec.CommandContext(ctx, "unshare", sb.getUnshareArgs(hc.Dir, append([]string{commandPath}, args[1:]...))...)
+
+	default:
+		if !slices.Contains(sandboxRestrictedCommands, name) {
+			slog.Warn(fmt.Sprintf("Refusing to run command %s outside of a sandbox while evaluating PKGBUILD in %s", name, sb.Dir))
+			return interp.NewExitStatus(127)
+		}
+
+		if !sb.isInside(hc.Dir) {
+			slog.Warn(fmt.Sprintf("Refusing to run command %s outside of the package directory while evaluating PKGBUILD in %s", name, sb.Dir))
+			return interp.NewExitStatus(126)
+		}
+
+		for _, arg := range args[1:] {
+			if !sb.isRestrictedArgAllowed(hc.Dir, arg) {
+				slog.Warn(fmt.Sprintf("Refusing to run command %s with path %s outside of the package directory while evaluating PKGBUILD in %s", name, arg, sb.Dir))
+				return interp.NewExitStatus(126)
+			}
+		}
+
+		cmd = exec.CommandContext(ctx, commandPath, args[1:]...)
+	}
+
+	cmd.Dir = hc.Dir
+	cmd.Env = env
+	cmd.Stdin = hc.Stdin
+	cmd.Stdout = hc.Stdout
+	cmd.Stderr = hc.Stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+
+		if errors.As(err, &exitErr) {
+			return interp.NewExitStatus(uint8(exitErr.ExitCode()))
+		}
+
+		return interp.NewExitStatus(127)
+	}
+
+	return nil
+}
+
+// isRestrictedArgAllowed checks an argument of a command run without a
+// sandbox. Anything that could be a path, including the value of an option,
+// must resolve to a file inside the package directory, following symlinks.
+func (sb *sandbox) isRestrictedArgAllowed(dir string, arg string) bool {
+	candidates := []string{arg}
+
+	if strings.HasPrefix(arg, "-") {
+		for index := 1; index < len(arg); index++ {
+			candidates = append(candidates, arg[index:])
+		}
+
+		if index := strings.Index(arg, "="); index >= 0 {
+			candidates = append(candidates, arg[index+1:])
+		}
+	}
+
+	for _, candidate := range candidates {
+		candidatePath := candidate
+
+		if !filepath.IsAbs(candidatePath) {
+			candidatePath = filepath.Join(dir, candidatePath)
+		}
+
+		if candidate != ".." && !strings.Contains(candidate, "/") {
+			if _, err := os.Lstat(candidatePath); err != nil {
+				continue
+			}
+		}
+
+		if !sb.isInside(candidatePath) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (sb *sandbox) isInside(name string) bool {
+	name = filepath.Clean(name)
+
+	if resolved, err := filepath.EvalSymlinks(name); err == nil {
+		name = resolved
+	}
+
+	dir := sb.Dir
+
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+
+	return name == dir || strings.HasPrefix(name, dir+string(filepath.Separator))
+}
+
+func (sb *sandbox) openHandler(ctx context.Context, name string, flag int, perm os.FileMode) (io.ReadWriteCloser, error) {
+	hc := interp.HandlerCtx(ctx)
+	openPath := name
+
+	if openPath != "" && !filepath.IsAbs(openPath) {
+		openPath = filepath.Join(hc.Dir, openPath)
+	}
+
+	openPath = filepath.Clean(openPath)
+
+	if openPath == os.DevNull {
+		return interp.DefaultOpenHandler()(ctx, name, flag, perm)
+	}
+
+	if strings.HasPrefix(openPath, filepath.Join(os.TempDir(), "sh-interp-")) {
+		return interp.DefaultOpenHandler()(ctx, name, flag, perm)
+	}
+
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_APPEND|os.O_CREATE|os.O_TRUNC) != 0 {
+		return nil, &os.PathError{Op: "open", Path: name, Err: syscall.EROFS}
+	}
+
+	if openPath != sb.Dir && !strings.HasPrefix(openPath, sb.Dir+string(filepath.Separator)) {
+		return nil, &os.PathError{Op: "open", Path: name, Err: syscall.EACCES}
+	}
+
+	return interp.DefaultOpenHandler()(ctx, name, flag, perm)
+}
+
+func logSuspiciousPkgbuild(dir string, file *syntax.File) {
+	printer := syntax.NewPrinter(syntax.SingleLine(true))
+
+	syntax.Walk(file, func(node syntax.Node) bool {
+		switch node := node.(type) {
+		case *syntax.FuncDecl:
+			return false
+
+		case *syntax.CmdSubst:
+			text := strings.Builder{}
+
+			if err := printer.Print(&text, node); err != nil {
+				text.WriteString("$(...)")
+			}
+
+			slog.Warn(fmt.Sprintf("Suspicious top-level command substitution in PKGBUILD in %s at line %d: %s", dir, node.Pos().Line(), text.String()))
+		}
+
+		return true
+	})
+}
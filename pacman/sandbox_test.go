@@ -0,0 +1,102 @@
+package pacman
+
+import (
+	"bytes"
+	"fmt"
+	"mvdan.cc/sh/v3/syntax"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestSandboxConfinesReads(t *testing.T) {
+	t.Setenv("AUR_BUILDER_TEST_SECRET", "leaked-environment")
+
+	outside := t.TempDir()
+	secret := path.Join(outside, "secret")
+
+	if err := os.WriteFile(secret, []byte("leaked-file\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		mode      string
+		available func() bool
+	}{
+		{SandboxRestricted, func() bool { return true }},
+		{SandboxUnshare, isUnshareAvailable},
+		{SandboxBwrap, func() bool {
+			_, err := exec.LookPath("bwrap")
+			return err == nil
+		}},
+	}
+
+	reads := []string{
+		fmt.Sprintf("cat %s", secret),
+		fmt.Sprintf("cat ../../../../../../../../..%s", secret),
+		fmt.Sprintf("cd %s && cat secret", outside),
+		fmt.Sprintf("grep -h leaked %s", secret),
+		fmt.Sprintf("cut -c1- --output-delimiter=x %s", secret),
+		fmt.Sprintf("cat /proc/%d/environ", os.Getpid()),
+		"cat /proc/self/environ",
+	}
+
+	for _, test := range tests {
+		t.Run(test.mode, func(t *testing.T) {
+			if !test.available() {
+				t.Skipf("%s sandbox not available", test.mode)
+			}
+
+			dir := t.TempDir()
+
+			if err := os.WriteFile(path.Join(dir, "inside"), []byte("inside\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := os.Symlink(secret, path.Join(dir, "link")); err != nil {
+				t.Fatal(err)
+			}
+
+			sb, err := newSandbox(dir, test.mode)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer sb.Close()
+
+			if out := runSandboxTest(t, sb, "cat inside"); out != "inside\n" {
+				t.Fatalf("expected to read a file inside the package directory, got %q", out)
+			}
+
+			for _, read := range append(reads, "cat link") {
+				if out := runSandboxTest(t, sb, read); strings.Contains(out, "leaked") {
+					t.Errorf("%s: read outside the package directory: %q", read, out)
+				}
+			}
+		})
+	}
+}
+
+func runSandboxTest(t *testing.T, sb *sandbox, script string) string {
+	t.Helper()
+
+	stdout := &bytes.Buffer{}
+	runner, _, err := newPkgbuildRunner(sb, nil, PkgbuildOptions{AllowExec: true}, stdout)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := syntax.NewParser().Parse(strings.NewReader(script), "test")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = runPkgbuild(runner, file)
+
+	return stdout.String()
+}
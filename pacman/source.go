@@ -46,7 +46,25 @@ func (source *Source) GetFolder() string {
 		return source.Folder
 	}
 
-	return strings.SplitN(path.Base(source.Url), ".", 2)[0]
+	name := path.Base(strings.TrimSuffix(source.GetVcsUrl(), "/"))
+
+	switch source.VcsType {
+	case VcsTypeBzr:
+		if index := strings.Index(name, "lp:"); index >= 0 {
+			name = name[index+3:]
+		}
+
+	case VcsTypeGit:
+		if index := strings.Index(name, ".git"); index >= 0 {
+			name = name[:index]
+		}
+	}
+
+	return name
+}
+
+func (source *Source) GetVcsUrl() string {
+	return strings.SplitN(source.Url, "?", 2)[0]
 }
 
 var (
@@ -0,0 +1,323 @@
+package pacman
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	srcinfoHashAttributes = []string{
+		"cksums", "md5sums", "sha1sums", "sha224sums", "sha256sums", "sha384sums", "sha512sums", "b2sums",
+	}
+
+	srcinfoGlobalSingleAttributes = []string{
+		"pkgdesc", "pkgver", "pkgrel", "epoch", "url", "install", "changelog",
+	}
+
+	srcinfoGlobalMultiAttributes = append([]string{
+		"arch", "groups", "license", "checkdepends", "makedepends", "depends", "optdepends", "provides",
+		"conflicts", "replaces", "noextract", "options", "backup", "source", "validpgpkeys",
+	}, srcinfoHashAttributes...)
+
+	srcinfoPackageSingleAttributes = []string{
+		"pkgdesc", "url", "install", "changelog",
+	}
+
+	srcinfoPackageMultiAttributes = []string{
+		"arch", "groups", "license", "checkdepends", "depends", "optdepends", "provides", "conflicts", "replaces",
+		"options", "backup",
+	}
+
+	srcinfoArchAttributes = append([]string{
+		"source", "provides", "conflicts", "depends", "replaces", "optdepends", "makedepends", "checkdepends",
+	}, srcinfoHashAttributes...)
+)
+
+func LoadSrcinfo(srcinfoPath string) (*PkgInfo, error) {
+	file, err := os.Open(srcinfoPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	return ParseSrcinfo(file)
+}
+
+func ParseSrcinfo(reader io.Reader) (*PkgInfo, error) {
+	pkginfo := &PkgInfo{}
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+
+	var pkg *PkgInfoPackage
+
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+
+		if !found {
+			return nil, errors.New(fmt.Sprintf("invalid .SRCINFO line %d: %s", lineNumber, line))
+		}
+
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+
+		switch {
+		case key == "pkgbase":
+			if pkginfo.Pkgbase != "" {
+				return nil, errors.New(fmt.Sprintf("duplicate pkgbase in .SRCINFO line %d", lineNumber))
+			}
+
+			pkginfo.Pkgbase = value
+
+		case key == "pkgname":
+			pkg = &PkgInfoPackage{
+				Pkgname:   value,
+				Overrides: map[string][]string{},
+			}
+
+			pkginfo.Pkgname = append(pkginfo.Pkgname, value)
+			pkginfo.Packages = append(pkginfo.Packages, pkg)
+
+		case pkginfo.Pkgbase == "":
+			return nil, errors.New(fmt.Sprintf("expected pkgbase before .SRCINFO line %d", lineNumber))
+
+		case pkg != nil:
+			if value == "" {
+				if _, ok := pkg.Overrides[key]; !ok {
+					pkg.Overrides[key] = nil
+				}
+			} else {
+				pkg.Overrides[key] = append(pkg.Overrides[key], value)
+			}
+
+		case value != "":
+			field, arch, _ := strings.Cut(key, "_")
+			pkginfo.addValue(field, arch, value)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if pkginfo.Pkgbase == "" {
+		return nil, errors.New("missing pkgbase in .SRCINFO")
+	}
+
+	return pkginfo, nil
+}
+
+func (pkginfo *PkgInfo) SaveSrcinfo(srcinfoPath string) error {
+	file, err := os.Create(srcinfoPath)
+
+	if err != nil {
+		return err
+	}
+
+	if err := pkginfo.WriteSrcinfo(file); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+func (pkginfo *PkgInfo) WriteSrcinfo(writer io.Writer) error {
+	buf := bufio.NewWriter(writer)
+
+	fmt.Fprintf(buf, "pkgbase = %s\n", pkginfo.Pkgbase)
+
+	for _, attr := range append(slices.Clone(srcinfoGlobalSingleAttributes), srcinfoGlobalMultiAttributes...) {
+		for _, item := range pkginfo.getValues(attr) {
+			if item.Arch == "" {
+				fmt.Fprintf(buf, "\t%s = %s\n", attr, item.Value)
+			}
+		}
+	}
+
+	for _, arch := range pkginfo.getArchitectures() {
+		for _, attr := range srcinfoArchAttributes {
+			for _, item := range pkginfo.getValues(attr) {
+				if item.Arch == arch {
+					fmt.Fprintf(buf, "\t%s_%s = %s\n", attr, arch, item.Value)
+				}
+			}
+		}
+	}
+
+	fmt.Fprintln(buf)
+
+	for _, pkgname := range pkginfo.Pkgname {
+		fmt.Fprintf(buf, "pkgname = %s\n", pkgname)
+
+		pkg := pkginfo.GetPackage(pkgname)
+
+		if pkg == nil {
+			fmt.Fprintln(buf)
+			continue
+		}
+
+		keys := append(slices.Clone(srcinfoPackageSingleAttributes), srcinfoPackageMultiAttributes...)
+
+		arches := pkginfo.getArchitectures()
+
+		if packageArches, ok := pkg.Overrides["arch"]; ok {
+			arches = packageArches
+		}
+
+		for _, arch := range arches {
+			if arch == "any" {
+				continue
+			}
+
+			for _, attr := range srcinfoArchAttributes {
+				keys = append(keys, fmt.Sprintf("%s_%s", attr, arch))
+			}
+		}
+
+		var extraKeys []string
+
+		for key := range pkg.Overrides {
+			if !slices.Contains(keys, key) {
+				extraKeys = append(extraKeys, key)
+			}
+		}
+
+		sort.Strings(extraKeys)
+
+		for _, key := range append(keys, extraKeys...) {
+			values, ok := pkg.Overrides[key]
+
+			if !ok {
+				continue
+			}
+
+			if len(values) == 0 {
+				fmt.Fprintf(buf, "\t%s = \n", key)
+				continue
+			}
+
+			for _, value := range values {
+				fmt.Fprintf(buf, "\t%s = %s\n", key, value)
+			}
+		}
+
+		fmt.Fprintln(buf)
+	}
+
+	return buf.Flush()
+}
+
+func (pkginfo *PkgInfo) getArchitectures() []string {
+	var result []string
+
+	for _, arch := range pkginfo.Arch {
+		if arch != "any" && !slices.Contains(result, arch) {
+			result = append(result, arch)
+		}
+	}
+
+	for _, attr := range srcinfoArchAttributes {
+		for _, item := range pkginfo.getValues(attr) {
+			if item.Arch != "" && !slices.Contains(result, item.Arch) {
+				result = append(result, item.Arch)
+			}
+		}
+	}
+
+	return result
+}
+
+func (pkginfo *PkgInfo) getValues(field string) []PkgInfoArchItem {
+	var values []string
+
+	switch field {
+	case "pkgver":
+		values = []string{pkginfo.Pkgver}
+	case "pkgrel":
+		values = []string{pkginfo.Pkgrel}
+	case "epoch":
+		if pkginfo.Epoch > 0 {
+			values = []string{strconv.Itoa(pkginfo.Epoch)}
+		}
+	case "pkgdesc":
+		values = []string{pkginfo.Pkgdesc}
+	case "url":
+		values = []string{pkginfo.Url}
+	case "install":
+		values = []string{pkginfo.Install}
+	case "changelog":
+		values = []string{pkginfo.Changelog}
+	case "arch":
+		values = pkginfo.Arch
+	case "groups":
+		values = pkginfo.Groups
+	case "license":
+		values = pkginfo.License
+	case "noextract":
+		values = pkginfo.NoExtract
+	case "options":
+		values = pkginfo.Options
+	case "backup":
+		values = pkginfo.Backup
+	case "validpgpkeys":
+		values = pkginfo.ValidPgpKeys
+	case "source":
+		return pkginfo.Source
+	case "depends":
+		return pkginfo.Depends
+	case "checkdepends":
+		return pkginfo.CheckDepends
+	case "makedepends":
+		return pkginfo.MakeDepends
+	case "optdepends":
+		return pkginfo.OptDepends
+	case "provides":
+		return pkginfo.Provides
+	case "conflicts":
+		return pkginfo.Conflicts
+	case "replaces":
+		return pkginfo.Replaces
+	case "cksums":
+		return pkginfo.CkSums
+	case "md5sums":
+		return pkginfo.Md5Sums
+	case "sha1sums":
+		return pkginfo.Sha1Sums
+	case "sha224sums":
+		return pkginfo.Sha224Sums
+	case "sha256sums":
+		return pkginfo.Sha256Sums
+	case "sha384sums":
+		return pkginfo.Sha384Sums
+	case "sha512sums":
+		return pkginfo.Sha512Sums
+	case "b2sums":
+		return pkginfo.B2Sums
+	}
+
+	var result []PkgInfoArchItem
+
+	for _, value := range values {
+		if value != "" {
+			result = append(result, PkgInfoArchItem{Value: value})
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,276 @@
+package pacman
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const testSrcinfoSimple = `pkgbase = yay
+	pkgdesc = Yet another yogurt. Pacman wrapper and AUR helper written in go.
+	pkgver = 12.4.2
+	pkgrel = 1
+	url = https://github.com/Jguer/yay
+	arch = i686
+	arch = pentium4
+	arch = x86_64
+	arch = arm
+	arch = armv7h
+	arch = armv6h
+	arch = aarch64
+	arch = riscv64
+	license = GPL-3.0-or-later
+	makedepends = go>=1.21
+	depends = pacman>6.1
+	depends = git
+	optdepends = sudo: privilege elevation
+	optdepends = doas: privilege elevation
+	options = !lto
+	source = yay-12.4.2.tar.gz::https://github.com/Jguer/yay/archive/v12.4.2.tar.gz
+	sha256sums = 5ffd8b7e4b0b5f6c4a1fa33a0ba0d3ce6ed5e3a1bb1f08d6d3b4a5fbd53b4a62
+
+pkgname = yay
+
+`
+
+const testSrcinfoSplit = `pkgbase = foo
+	pkgdesc = Foo tools
+	pkgver = 1.2.3
+	pkgrel = 2
+	epoch = 1
+	url = https://example.com/foo
+	install = foo.install
+	arch = x86_64
+	arch = aarch64
+	license = MIT
+	license = Apache-2.0
+	checkdepends = python-pytest
+	makedepends = cmake
+	makedepends = ninja
+	depends = glibc
+	provides = foo-tools
+	conflicts = foo-git
+	noextract = data.tar.gz
+	options = !debug
+	backup = etc/foo.conf
+	source = https://example.com/foo-1.2.3.tar.gz
+	source = foo.service
+	validpgpkeys = 0123456789ABCDEF0123456789ABCDEF01234567
+	sha256sums = SKIP
+	sha256sums = 2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae
+	b2sums = SKIP
+	b2sums = SKIP
+	source_x86_64 = foo-x86_64.patch
+	sha256sums_x86_64 = SKIP
+	b2sums_x86_64 = SKIP
+	source_aarch64 = foo-aarch64.patch
+	depends_aarch64 = libunwind
+	sha256sums_aarch64 = SKIP
+	b2sums_aarch64 = SKIP
+
+pkgname = foo
+	depends = glibc
+	depends = foo-libs
+	optdepends = bash-completion: completion
+	optdepends_x86_64 = intel-media-driver: hardware decoding
+
+pkgname = foo-libs
+	pkgdesc = Foo libraries
+	depends = 
+	provides = libfoo.so=1-64
+	backup = 
+	provides_x86_64 = libfoo-x86_64.so=1-64
+
+pkgname = foo-docs
+	pkgdesc = Foo documentation
+	arch = any
+	license = CC-BY-4.0
+	depends = 
+
+`
+
+func TestSrcinfoRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		srcinfo string
+	}{
+		{"simple", testSrcinfoSimple},
+		{"split", testSrcinfoSplit},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pkginfo, err := ParseSrcinfo(strings.NewReader(test.srcinfo))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			buf := bytes.Buffer{}
+
+			if err := pkginfo.WriteSrcinfo(&buf); err != nil {
+				t.Fatal(err)
+			}
+
+			if buf.String() != test.srcinfo {
+				t.Errorf("expected:\n%s\ngot:\n%s", test.srcinfo, buf.String())
+			}
+		})
+	}
+}
+
+func TestParseSrcinfo(t *testing.T) {
+	pkginfo, err := ParseSrcinfo(strings.NewReader(testSrcinfoSplit))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pkginfo.Pkgbase != "foo" || pkginfo.GetFullVersion() != "1:1.2.3-2" || !slices.Equal(pkginfo.Pkgname, []string{"foo", "foo-libs", "foo-docs"}) {
+		t.Errorf("unexpected package %s %s %v", pkginfo.Pkgbase, pkginfo.GetFullVersion(), pkginfo.Pkgname)
+	}
+
+	if !slices.Equal(pkginfo.Arch, []string{"x86_64", "aarch64"}) {
+		t.Errorf("unexpected arch %v", pkginfo.Arch)
+	}
+
+	expectedDepends := []PkgInfoArchItem{{Value: "glibc"}, {Arch: "aarch64", Value: "libunwind"}}
+
+	if !slices.Equal(pkginfo.Depends, expectedDepends) {
+		t.Errorf("expected depends %v, got %v", expectedDepends, pkginfo.Depends)
+	}
+
+	libs := pkginfo.GetPackage("foo-libs")
+
+	if libs == nil {
+		t.Fatal("missing package foo-libs")
+	}
+
+	if depends, ok := libs.Overrides["depends"]; !ok || len(depends) != 0 {
+		t.Errorf("expected depends to be overridden with an empty array, got %v (%t)", depends, ok)
+	}
+
+	if provides := libs.Overrides["provides_x86_64"]; !slices.Equal(provides, []string{"libfoo-x86_64.so=1-64"}) {
+		t.Errorf("unexpected per-arch provides %v", provides)
+	}
+
+	if _, ok := pkginfo.GetPackage("foo").Overrides["pkgdesc"]; ok {
+		t.Error("expected pkgdesc not to be overridden for foo")
+	}
+}
+
+func TestParseSrcinfoFormatting(t *testing.T) {
+	srcinfo := "# Generated by makepkg 6.1.0\n# Tue Oct 14 12:00:00 UTC 2025\n\npkgbase=yay\n  pkgver =  12.4.2\n\tpkgrel = 1\n\n\n   # comment\n\tarch = x86_64\n\tdepends = pacman>6.1\n\npkgname = yay\n\tdepends =\n"
+	expected := "pkgbase = yay\n\tpkgver = 12.4.2\n\tpkgrel = 1\n\tarch = x86_64\n\tdepends = pacman>6.1\n\npkgname = yay\n\tdepends = \n\n"
+
+	pkginfo, err := ParseSrcinfo(strings.NewReader(srcinfo))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := bytes.Buffer{}
+
+	if err := pkginfo.WriteSrcinfo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != expected {
+		t.Errorf("expected:\n%q\ngot:\n%q", expected, buf.String())
+	}
+}
+
+func TestParseSrcinfoInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		srcinfo string
+	}{
+		{"missing pkgbase", "pkgname = foo\n"},
+		{"attribute before pkgbase", "pkgver = 1\npkgbase = foo\n"},
+		{"duplicate pkgbase", "pkgbase = foo\npkgbase = bar\n"},
+		{"missing equals", "pkgbase = foo\n\tpkgver 1\n"},
+		{"empty", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ParseSrcinfo(strings.NewReader(test.srcinfo)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestWriteSrcinfoMatchesMakepkg(t *testing.T) {
+	if _, err := exec.LookPath("makepkg"); err != nil {
+		t.Skip("makepkg not installed")
+	}
+
+	dir := t.TempDir()
+	pkgbuild := `pkgbase=foo
+pkgname=(foo foo-libs foo-docs)
+pkgver=1.2.3
+pkgrel=2
+epoch=1
+pkgdesc='Foo tools'
+url='https://example.com/foo'
+arch=(x86_64 aarch64)
+license=(MIT)
+makedepends=(cmake)
+depends=(glibc)
+source=(foo.service)
+source_x86_64=(foo-x86_64.patch)
+depends_aarch64=(libunwind)
+sha256sums=(SKIP)
+sha256sums_x86_64=(SKIP)
+
+package_foo() {
+  depends=(glibc foo-libs)
+  optdepends_x86_64=('intel-media-driver: hardware decoding')
+}
+
+package_foo-libs() {
+  pkgdesc='Foo libraries'
+  depends=()
+}
+
+package_foo-docs() {
+  arch=(any)
+  depends=()
+}
+`
+
+	if err := os.WriteFile(path.Join(dir, "PKGBUILD"), []byte(pkgbuild), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command("makepkg", "--printsrcinfo")
+	cmd.Dir = dir
+	expected, err := cmd.Output()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkgbuildInfo, err := LoadPkgbuild(path.Join(dir, "PKGBUILD"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pkginfo := pkgbuildInfo.GetPkgInfo()
+
+	buf := bytes.Buffer{}
+
+	if err := pkginfo.WriteSrcinfo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != string(expected) {
+		t.Errorf("expected makepkg output:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
@@ -0,0 +1,105 @@
+package pacman
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+const (
+	VcsTypeBzr    = "bzr"
+	VcsTypeFossil = "fossil"
+	VcsTypeGit    = "git"
+	VcsTypeHg     = "hg"
+	VcsTypeSvn    = "svn"
+
+	FragmentTypeBranch   = "branch"
+	FragmentTypeCommit   = "commit"
+	FragmentTypeRevision = "revision"
+	FragmentTypeTag      = "tag"
+)
+
+func (source *Source) IsVcs() bool {
+	switch source.VcsType {
+	case VcsTypeBzr, VcsTypeFossil, VcsTypeGit, VcsTypeHg, VcsTypeSvn:
+		return true
+	}
+
+	return false
+}
+
+func (source *Source) GetPinnedFragmentType() string {
+	switch source.VcsType {
+	case VcsTypeGit, VcsTypeFossil:
+		return FragmentTypeCommit
+	case VcsTypeHg, VcsTypeSvn, VcsTypeBzr:
+		return FragmentTypeRevision
+	}
+
+	return ""
+}
+
+func (source *Source) IsPinned() bool {
+	return source.FragmentType != "" && source.FragmentType == source.GetPinnedFragmentType()
+}
+
+func (source *Source) Pin(revision string) {
+	source.FragmentType = source.GetPinnedFragmentType()
+	source.FragmentValue = revision
+}
+
+func GetVcsRevision(vcsType string, dir string) (string, error) {
+	switch vcsType {
+	case VcsTypeHg:
+		return runVcsCommand(dir, "hg", "log", "--rev", ".", "--template", "{node}")
+
+	case VcsTypeSvn:
+		return runVcsCommand(dir, "svn", "info", "--show-item", "revision")
+
+	case VcsTypeBzr:
+		return runVcsCommand(dir, "bzr", "revno", "--tree")
+
+	case VcsTypeFossil:
+		out, err := runVcsCommand(dir, "fossil", "info")
+
+		if err != nil {
+			return "", err
+		}
+
+		for _, line := range strings.Split(out, "\n") {
+			if value, found := strings.CutPrefix(line, "checkout:"); found {
+				if fields := strings.Fields(value); len(fields) > 0 {
+					return fields[0], nil
+				}
+			}
+		}
+
+		return "", errors.New(fmt.Sprintf("could not find fossil checkout in %s", dir))
+	}
+
+	return "", errors.New(fmt.Sprintf("unsupported vcs type %s", vcsType))
+}
+
+func runVcsCommand(dir string, name string, args ...string) (string, error) {
+	outBuf := &bytes.Buffer{}
+	errBuf := &bytes.Buffer{}
+
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	cmd.Stdout = outBuf
+	cmd.Stderr = errBuf
+
+	if err := cmd.Run(); err != nil {
+		return "", errors.New(fmt.Sprintf("%s failed in %s: %s\n%s", name, dir, err, errBuf.String()))
+	}
+
+	result := strings.TrimSpace(outBuf.String())
+
+	if result == "" {
+		return "", errors.New(fmt.Sprintf("%s returned no revision in %s", name, dir))
+	}
+
+	return result, nil
+}
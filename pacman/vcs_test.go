@@ -0,0 +1,177 @@
+package pacman
+
+import (
+	"os"
+	"os/exec"
+	"path"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var vcsHashRegex = regexp.MustCompile(`^[0-9a-f]{40}([0-9a-f]{24})?$`)
+
+func TestGetVcsRevisionHg(t *testing.T) {
+	requireVcsCommand(t, "hg")
+
+	dir := t.TempDir()
+	t.Setenv("HGUSER", "test <test@example.com>")
+	t.Setenv("HGRCPATH", "")
+
+	runTestCommand(t, dir, "hg", "init")
+	writeTestFile(t, dir, "one")
+	runTestCommand(t, dir, "hg", "commit", "--addremove", "-m", "one")
+	first := runTestCommand(t, dir, "hg", "log", "--rev", "tip", "--template", "{node}")
+	writeTestFile(t, dir, "two")
+	runTestCommand(t, dir, "hg", "commit", "--addremove", "-m", "two")
+	runTestCommand(t, dir, "hg", "update", "--rev", "0")
+
+	revision, err := GetVcsRevision(VcsTypeHg, dir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !vcsHashRegex.MatchString(revision) {
+		t.Fatalf("expected a node hash, got %q", revision)
+	}
+
+	if revision != first {
+		t.Errorf("expected working copy revision %s, got %s", first, revision)
+	}
+}
+
+func TestGetVcsRevisionSvn(t *testing.T) {
+	requireVcsCommand(t, "svn")
+	requireVcsCommand(t, "svnadmin")
+
+	dir := t.TempDir()
+	repo := path.Join(dir, "repo")
+	wc := path.Join(dir, "wc")
+
+	runTestCommand(t, dir, "svnadmin", "create", repo)
+	runTestCommand(t, dir, "svn", "checkout", "--quiet", "file://"+repo, wc)
+
+	writeTestFile(t, wc, "one")
+	runTestCommand(t, wc, "svn", "add", "--quiet", "one")
+	runTestCommand(t, wc, "svn", "commit", "--quiet", "-m", "one")
+	writeTestFile(t, wc, "two")
+	runTestCommand(t, wc, "svn", "add", "--quiet", "two")
+	runTestCommand(t, wc, "svn", "commit", "--quiet", "-m", "two")
+	runTestCommand(t, wc, "svn", "update", "--quiet")
+
+	revision, err := GetVcsRevision(VcsTypeSvn, wc)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if revision != "2" {
+		t.Errorf("expected revision 2, got %q", revision)
+	}
+}
+
+func TestGetVcsRevisionBzr(t *testing.T) {
+	requireVcsCommand(t, "bzr")
+
+	dir := t.TempDir()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("BZR_EMAIL", "test <test@example.com>")
+	t.Setenv("BRZ_EMAIL", "test <test@example.com>")
+
+	runTestCommand(t, dir, "bzr", "init", "--quiet")
+	writeTestFile(t, dir, "one")
+	runTestCommand(t, dir, "bzr", "add", "--quiet", "one")
+	runTestCommand(t, dir, "bzr", "commit", "--quiet", "-m", "one")
+	writeTestFile(t, dir, "two")
+	runTestCommand(t, dir, "bzr", "add", "--quiet", "two")
+	runTestCommand(t, dir, "bzr", "commit", "--quiet", "-m", "two")
+	runTestCommand(t, dir, "bzr", "update", "--quiet", "-r", "1")
+
+	revision, err := GetVcsRevision(VcsTypeBzr, dir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if revision != "1" {
+		t.Errorf("expected revision 1, got %q", revision)
+	}
+}
+
+func TestGetVcsRevisionFossil(t *testing.T) {
+	requireVcsCommand(t, "fossil")
+
+	dir := t.TempDir()
+	repo := path.Join(dir, "repo.fossil")
+	wc := path.Join(dir, "wc")
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("USER", "test")
+
+	if err := os.Mkdir(wc, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	runTestCommand(t, dir, "fossil", "init", repo)
+	runTestCommand(t, wc, "fossil", "open", repo)
+
+	before, err := GetVcsRevision(VcsTypeFossil, wc)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeTestFile(t, wc, "one")
+	runTestCommand(t, wc, "fossil", "add", "one")
+	runTestCommand(t, wc, "fossil", "commit", "-m", "one", "--no-warnings")
+
+	revision, err := GetVcsRevision(VcsTypeFossil, wc)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !vcsHashRegex.MatchString(revision) {
+		t.Fatalf("expected a checkout hash, got %q", revision)
+	}
+
+	if revision == before {
+		t.Errorf("expected checkout to change after commit, still %s", revision)
+	}
+}
+
+func TestGetVcsRevisionUnsupported(t *testing.T) {
+	if _, err := GetVcsRevision("cvs", t.TempDir()); err == nil {
+		t.Error("expected an error for an unsupported vcs type")
+	}
+}
+
+func requireVcsCommand(t *testing.T, name string) {
+	t.Helper()
+
+	if _, err := exec.LookPath(name); err != nil {
+		t.Skipf("%s not installed", name)
+	}
+}
+
+func writeTestFile(t *testing.T, dir string, name string) {
+	t.Helper()
+
+	if err := os.WriteFile(path.Join(dir, name), []byte(name+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func runTestCommand(t *testing.T, dir string, name string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+
+	if err != nil {
+		t.Fatalf("%s %s failed: %s\n%s", name, strings.Join(args, " "), err, out)
+	}
+
+	return strings.TrimSpace(string(out))
+}
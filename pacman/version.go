@@ -1,32 +1,270 @@
 package pacman
 
 import (
-	"bytes"
+	"errors"
 	"fmt"
-	"os/exec"
+	"regexp"
 	"strconv"
 	"strings"
 )
 
-func IsVersionNewer(oldVersion string, newVersion string) (bool, error) {
-	outBuf := &bytes.Buffer{}
+var pkgrelRegex = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+var epochRegex = regexp.MustCompile(`^[0-9]*$`)
+
+type Version struct {
+	Epoch  string
+	Pkgver string
+	Pkgrel string
+}
+
+func NewVersion(epoch string, pkgver string, pkgrel int, subpkgrel int) *Version {
+	pkgrelstr := strconv.Itoa(pkgrel)
+
+	if subpkgrel > 0 {
+		pkgrelstr = fmt.Sprintf("%s.%d", pkgrelstr, subpkgrel)
+	}
+
+	return &Version{
+		Epoch:  epoch,
+		Pkgver: pkgver,
+		Pkgrel: pkgrelstr,
+	}
+}
+
+func ParseVersion(version string) (*Version, error) {
+	result := &Version{}
+	rest := version
+
+	// Like alpm, only leading digits followed by a colon are an epoch. Anything
+	// else is part of pkgver, and an empty epoch is the same as no epoch.
+	if index := strings.Index(rest, ":"); index >= 0 && epochRegex.MatchString(rest[:index]) {
+		result.Epoch = rest[:index]
+		rest = rest[index+1:]
+	}
+
+	if index := strings.LastIndex(rest, "-"); index >= 0 {
+		result.Pkgrel = rest[index+1:]
+		rest = rest[:index]
 
-	cmd := exec.Command("vercmp", oldVersion, newVersion)
-	cmd.Stdout = outBuf
+		if !pkgrelRegex.MatchString(result.Pkgrel) {
+			return nil, errors.New(fmt.Sprintf("invalid pkgrel in version %s", version))
+		}
+	}
+
+	result.Pkgver = rest
 
-	err := cmd.Run()
+	if result.Pkgver == "" {
+		return nil, errors.New(fmt.Sprintf("invalid pkgver in version %s", version))
+	}
+
+	return result, nil
+}
+
+func (version *Version) String() string {
+	result := version.Pkgver
+
+	if version.Pkgrel != "" {
+		result = fmt.Sprintf("%s-%s", result, version.Pkgrel)
+	}
+
+	if version.Epoch != "" {
+		result = fmt.Sprintf("%s:%s", version.Epoch, result)
+	}
+
+	return result
+}
+
+func (version *Version) GetPkgrelParts() (int, int, error) {
+	if !pkgrelRegex.MatchString(version.Pkgrel) {
+		return 0, 0, errors.New("invalid pkgrel")
+	}
+
+	pkgrel, subpkgrel, _ := strings.Cut(version.Pkgrel, ".")
+	intpkgrel, err := strconv.Atoi(pkgrel)
 
 	if err != nil {
-		fmt.Println(outBuf.String())
-		return false, err
+		return 0, 0, err
+	}
+
+	if subpkgrel == "" {
+		return intpkgrel, 0, nil
+	}
+
+	intsubpkgrel, err := strconv.Atoi(subpkgrel)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return intpkgrel, intsubpkgrel, nil
+}
+
+func (version *Version) GetEpoch() string {
+	if version.Epoch == "" {
+		return "0"
+	}
+
+	return version.Epoch
+}
+
+func (version *Version) Compare(other *Version) int {
+	if result := rpmvercmp(version.GetEpoch(), other.GetEpoch()); result != 0 {
+		return result
+	}
+
+	if result := rpmvercmp(version.Pkgver, other.Pkgver); result != 0 {
+		return result
+	}
+
+	if version.Pkgrel == "" || other.Pkgrel == "" {
+		return 0
+	}
+
+	return rpmvercmp(version.Pkgrel, other.Pkgrel)
+}
+
+func CompareVersions(oldVersion string, newVersion string) (int, error) {
+	if oldVersion == newVersion {
+		return 0, nil
 	}
 
-	valueStr := strings.SplitN(outBuf.String(), "\n", 2)[0]
-	value, err := strconv.Atoi(valueStr)
+	if oldVersion == "" {
+		return -1, nil
+	}
+
+	if newVersion == "" {
+		return 1, nil
+	}
+
+	oldParsed, err := ParseVersion(oldVersion)
+
+	if err != nil {
+		return 0, err
+	}
+
+	newParsed, err := ParseVersion(newVersion)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return oldParsed.Compare(newParsed), nil
+}
+
+func IsVersionNewer(oldVersion string, newVersion string) (bool, error) {
+	result, err := CompareVersions(oldVersion, newVersion)
 
 	if err != nil {
 		return false, err
 	}
 
-	return value < 0, nil
+	return result < 0, nil
+}
+
+func ComparePkgver(a string, b string) int {
+	return rpmvercmp(a, b)
+}
+
+func rpmvercmp(a string, b string) int {
+	if a == b {
+		return 0
+	}
+
+	one, two := 0, 0
+
+	for one < len(a) && two < len(b) {
+		start1, start2 := one, two
+
+		for one < len(a) && !isAlnum(a[one]) {
+			one++
+		}
+
+		for two < len(b) && !isAlnum(b[two]) {
+			two++
+		}
+
+		if one >= len(a) || two >= len(b) {
+			break
+		}
+
+		if one-start1 != two-start2 {
+			if one-start1 < two-start2 {
+				return -1
+			}
+
+			return 1
+		}
+
+		end1, end2 := one, two
+		isNum := isDigit(a[one])
+
+		if isNum {
+			for end1 < len(a) && isDigit(a[end1]) {
+				end1++
+			}
+
+			for end2 < len(b) && isDigit(b[end2]) {
+				end2++
+			}
+		} else {
+			for end1 < len(a) && isAlpha(a[end1]) {
+				end1++
+			}
+
+			for end2 < len(b) && isAlpha(b[end2]) {
+				end2++
+			}
+		}
+
+		if two == end2 {
+			if isNum {
+				return 1
+			}
+
+			return -1
+		}
+
+		segment1, segment2 := a[one:end1], b[two:end2]
+
+		if isNum {
+			segment1 = strings.TrimLeft(segment1, "0")
+			segment2 = strings.TrimLeft(segment2, "0")
+
+			if len(segment1) > len(segment2) {
+				return 1
+			}
+
+			if len(segment2) > len(segment1) {
+				return -1
+			}
+		}
+
+		if result := strings.Compare(segment1, segment2); result != 0 {
+			return result
+		}
+
+		one, two = end1, end2
+	}
+
+	if one >= len(a) && two >= len(b) {
+		return 0
+	}
+
+	if (one >= len(a) && !isAlpha(b[two])) || (one < len(a) && isAlpha(a[one])) {
+		return -1
+	}
+
+	return 1
+}
+
+func isAlpha(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isAlnum(c byte) bool {
+	return isAlpha(c) || isDigit(c)
 }
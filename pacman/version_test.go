@@ -0,0 +1,159 @@
+package pacman
+
+import (
+	"testing"
+)
+
+// Vectors from pacman's test/util/vercmptest.sh.
+var vercmpTests = []struct {
+	a        string
+	b        string
+	expected int
+}{
+	// all similar length, no pkgrel
+	{"1.5.0", "1.5.0", 0},
+	{"1.5.1", "1.5.0", 1},
+
+	// mixed length
+	{"1.5.1", "1.5", 1},
+
+	// with pkgrel, simple
+	{"1.5.0-1", "1.5.0-1", 0},
+	{"1.5.0-1", "1.5.0-2", -1},
+	{"1.5.0-1", "1.5.1-1", -1},
+	{"1.5.0-2", "1.5.1-1", -1},
+
+	// with pkgrel, mixed lengths
+	{"1.5-1", "1.5.1-1", -1},
+	{"1.5-2", "1.5.1-1", -1},
+	{"1.5-2", "1.5.1-2", -1},
+
+	// mixed pkgrel inclusion
+	{"1.5", "1.5-1", 0},
+	{"1.5-1", "1.5", 0},
+	{"1.1-1", "1.1", 0},
+	{"1.0-1", "1.1", -1},
+	{"1.1-1", "1.0", 1},
+
+	// alphanumeric versions
+	{"1.5b-1", "1.5-1", -1},
+	{"1.5b", "1.5", -1},
+	{"1.5b-1", "1.5", -1},
+	{"1.5b", "1.5.1", -1},
+
+	// from the manpage
+	{"1.0a", "1.0alpha", -1},
+	{"1.0alpha", "1.0b", -1},
+	{"1.0b", "1.0beta", -1},
+	{"1.0beta", "1.0rc", -1},
+	{"1.0rc", "1.0", -1},
+
+	// alpha-dotted versions
+	{"1.5.a", "1.5", 1},
+	{"1.5.b", "1.5.a", 1},
+	{"1.5.1", "1.5.b", 1},
+
+	// alpha dots and dashes
+	{"1.5.b-1", "1.5.b", 0},
+	{"1.5-1", "1.5.b", -1},
+
+	// same/similar content, differing separators
+	{"2.0", "2_0", 0},
+	{"2.0_a", "2_0.a", 0},
+	{"2.0a", "2.0.a", -1},
+	{"2___a", "2_a", 1},
+
+	// epoch included version comparisons
+	{"0:1.0", "0:1.0", 0},
+	{"0:1.0", "0:1.1", -1},
+	{"1:1.0", "0:1.0", 1},
+	{"1:1.0", "0:1.1", 1},
+	{"1:1.0", "2:1.1", -1},
+
+	// epoch + sometimes present pkgrel
+	{"1:1.0", "0:1.0-1", 1},
+	{"1:1.0-1", "0:1.1-1", 1},
+
+	// epoch included on one version
+	{"0:1.0", "1.0", 0},
+	{"0:1.0", "1.1", -1},
+	{"0:1.1", "1.0", 1},
+	{"1:1.0", "1.0", 1},
+	{"1:1.0", "1.1", 1},
+	{"1:1.1", "1.1", 1},
+
+	// not in vercmptest.sh: alpm treats anything but digits before the colon as
+	// part of pkgver with epoch 0
+	{":1.0", "0:1.0", 0},
+	{"x:1.0", "1.0", -1},
+	{"1:1.0", "x:2.0", 1},
+	{"x:1.0-1", "x:1.1-1", -1},
+}
+
+func TestCompareVersions(t *testing.T) {
+	for _, test := range vercmpTests {
+		result, err := CompareVersions(test.a, test.b)
+
+		if err != nil {
+			t.Errorf("%s <=> %s: %s", test.a, test.b, err)
+			continue
+		}
+
+		if result != test.expected {
+			t.Errorf("%s <=> %s: expected %d, got %d", test.a, test.b, test.expected, result)
+		}
+
+		result, err = CompareVersions(test.b, test.a)
+
+		if err != nil {
+			t.Errorf("%s <=> %s: %s", test.b, test.a, err)
+			continue
+		}
+
+		if result != -test.expected {
+			t.Errorf("%s <=> %s: expected %d, got %d", test.b, test.a, -test.expected, result)
+		}
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected *Version
+	}{
+		{"1.0", &Version{Pkgver: "1.0"}},
+		{"1.0-2", &Version{Pkgver: "1.0", Pkgrel: "2"}},
+		{"1.0-2.1", &Version{Pkgver: "1.0", Pkgrel: "2.1"}},
+		{"3:1.0-2", &Version{Epoch: "3", Pkgver: "1.0", Pkgrel: "2"}},
+		{"1.0.r12.gabc-1", &Version{Pkgver: "1.0.r12.gabc", Pkgrel: "1"}},
+		{"x:1.0-1", &Version{Pkgver: "x:1.0", Pkgrel: "1"}},
+		{"1a:1.0-1", &Version{Pkgver: "1a:1.0", Pkgrel: "1"}},
+	}
+
+	for _, test := range tests {
+		result, err := ParseVersion(test.version)
+
+		if err != nil {
+			t.Errorf("%s: %s", test.version, err)
+			continue
+		}
+
+		if *result != *test.expected {
+			t.Errorf("%s: expected %+v, got %+v", test.version, test.expected, result)
+		}
+
+		if result.String() != test.version {
+			t.Errorf("%s: round trip produced %s", test.version, result.String())
+		}
+	}
+
+	if result, err := ParseVersion(":1.0-1"); err != nil || *result != (Version{Pkgver: "1.0", Pkgrel: "1"}) {
+		t.Errorf(":1.0-1: expected an empty epoch to be ignored, got %+v (%v)", result, err)
+	}
+
+	for _, version := range []string{"1.0-x", "-1", "1:"} {
+		if _, err := ParseVersion(version); err == nil {
+			t.Errorf("%s: expected an error", version)
+		}
+	}
+}
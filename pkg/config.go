@@ -9,10 +9,34 @@ import (
 )
 
 type PackageConfig struct {
-	Source    string                  `yaml:"source,omitempty"`
+	Source             string                  `yaml:"source,omitempty"`
+	UpstreamCommit     string                  `yaml:"upstreamCommit,omitempty"`
+	AurMaintainer      string                  `yaml:"aurMaintainer,omitempty"`
+	Overrides          *PackageConfigOverrides `yaml:"overrides,omitempty"`
+	Ignore             bool                    `yaml:"ignore,omitempty"`
+	Tags               []string                `yaml:"tags,omitempty"`
+	UpdatePolicy       *PackageUpdatePolicy    `yaml:"updatePolicy,omitempty"`
+	Vcs                *PackageVcs             `yaml:"vcs,omitempty"`
+	Repositories       []*PackageRepository    `yaml:"repositories,omitempty"`
+	UnmatchedOverrides []string                `yaml:"-"`
+}
+
+type PackageRepository struct {
+	Name      string                  `yaml:"name,omitempty"`
 	Overrides *PackageConfigOverrides `yaml:"overrides,omitempty"`
-	Ignore    bool                    `yaml:"ignore,omitempty"`
-	Vcs       *PackageVcs             `yaml:"vcs,omitempty"`
+}
+
+type PackageUpdatePolicy struct {
+	Hold           *PackageUpdateHold `yaml:"hold,omitempty"`
+	IgnoreVersions []string           `yaml:"ignoreVersions,omitempty"`
+	Allow          string             `yaml:"allow,omitempty"`
+	MinAge         string             `yaml:"minAge,omitempty"`
+}
+
+type PackageUpdateHold struct {
+	Version string `yaml:"version,omitempty"`
+	Reason  string `yaml:"reason,omitempty"`
+	Until   string `yaml:"until,omitempty"`
 }
 
 type PackageConfigOverrides struct {
@@ -21,10 +45,13 @@ type PackageConfigOverrides struct {
 	ClearDependsVersions bool                           `yaml:"clearDependsVersions,omitempty"`
 	ClearSignatures      bool                           `yaml:"clearSignatures,omitempty"`
 	DeleteFile           []string                       `yaml:"deleteFile,omitempty"`
+	FlattenPkgbuild      bool                           `yaml:"flattenPkgbuild,omitempty"`
 	ModifySection        []*PackageConfigModifySection  `yaml:"modifySection,omitempty"`
+	RemovePackage        []string                       `yaml:"removePackage,omitempty"`
 	RemoveSource         []string                       `yaml:"removeSource,omitempty"`
 	RenameFile           []*PackageConfigOverrideFromTo `yaml:"renameFile,omitempty"`
 	RenamePackage        []*PackageConfigOverrideFromTo `yaml:"renamePackage,omitempty"`
+	ReplaceDependency    map[string]string              `yaml:"replaceDependency,omitempty"`
 }
 
 type PackageConfigOverrideFromTo struct {
@@ -47,8 +74,26 @@ type PackageConfigModifySection struct {
 type PackageVcs struct {
 	Pkgver          string                         `yaml:"pkgver,omitempty"`
 	Pkgrel          int                            `yaml:"pkgrel,omitempty"`
+	LastBump        string                         `yaml:"lastBump,omitempty"`
 	SourceOverrides []*PackageConfigOverrideFromTo `yaml:"sourceOverrides,omitempty"`
 	Submodules      map[string]PackageVcsSubmodule `yaml:"submodules,omitempty"`
+	FollowTags      map[string]string              `yaml:"followTags,omitempty"`
+	Tags            []*PackageVcsTag               `yaml:"tags,omitempty"`
+	Policy          *PackageVcsPolicy              `yaml:"policy,omitempty"`
+	MovedTags       []string                       `yaml:"-"`
+}
+
+type PackageVcsPolicy struct {
+	MinInterval string   `yaml:"minInterval,omitempty"`
+	MinCommits  int      `yaml:"minCommits,omitempty"`
+	NewTagOnly  bool     `yaml:"newTagOnly,omitempty"`
+	Paths       []string `yaml:"paths,omitempty"`
+}
+
+type PackageVcsTag struct {
+	Source string `yaml:"source,omitempty"`
+	Tag    string `yaml:"tag,omitempty"`
+	Commit string `yaml:"commit,omitempty"`
 }
 
 type PackageVcsSubmodule struct {
@@ -0,0 +1,98 @@
+package pkg
+
+import (
+	"github.com/ryanpetris/aur-builder/config"
+	"sync"
+)
+
+type renamedDependency struct {
+	Pkgbase string
+	To      string
+}
+
+var renamedDependencies map[string]*renamedDependency
+var renamedDependenciesErr error
+var renamedDependenciesOnce sync.Once
+
+func (pconfig *PackageConfig) GetReplaceDependency(pkgbase string) (map[string]string, error) {
+	result := map[string]string{}
+
+	if config.GetReplaceRenamedDependencies() {
+		renames, err := getRenamedDependencies()
+
+		if err != nil {
+			return nil, err
+		}
+
+		for from, rename := range renames {
+			if rename.Pkgbase == pkgbase {
+				continue
+			}
+
+			result[from] = rename.To
+		}
+	}
+
+	for from, to := range config.GetReplaceDependency() {
+		result[from] = to
+	}
+
+	if pconfig.Overrides != nil {
+		for from, to := range pconfig.Overrides.ReplaceDependency {
+			result[from] = to
+		}
+	}
+
+	return result, nil
+}
+
+func getRenamedDependencies() (map[string]*renamedDependency, error) {
+	renamedDependenciesOnce.Do(func() {
+		packages, err := GetPackages()
+
+		if err != nil {
+			renamedDependenciesErr = err
+			return
+		}
+
+		result := map[string]*renamedDependency{}
+
+		for _, pkgbase := range packages {
+			pconfig, err := LoadConfig(pkgbase)
+
+			if err != nil {
+				renamedDependenciesErr = err
+				return
+			}
+
+			if pconfig.Overrides == nil {
+				continue
+			}
+
+			for _, rename := range pconfig.Overrides.RenamePackage {
+				from := rename.From
+
+				if from == "" {
+					from = pkgbase
+				}
+
+				if rename.To == "" || from == rename.To {
+					continue
+				}
+
+				result[from] = &renamedDependency{
+					Pkgbase: pkgbase,
+					To:      rename.To,
+				}
+			}
+		}
+
+		renamedDependencies = result
+	})
+
+	if renamedDependenciesErr != nil {
+		return nil, renamedDependenciesErr
+	}
+
+	return renamedDependencies, nil
+}
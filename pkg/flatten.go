@@ -0,0 +1,307 @@
+package pkg
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/ryanpetris/aur-builder/config"
+	"github.com/ryanpetris/aur-builder/pacman"
+	"log/slog"
+	"mvdan.cc/sh/v3/syntax"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+const (
+	appendMarker = "# aur-builder: appended overrides"
+)
+
+type pkgbuildAssignment struct {
+	StartLine int
+	EndLine   int
+	Append    bool
+	Words     []string
+}
+
+func (pconfig *PackageConfig) ShouldFlattenPkgbuild() bool {
+	if config.GetFlattenPkgbuild() {
+		return true
+	}
+
+	return pconfig.Overrides != nil && pconfig.Overrides.FlattenPkgbuild
+}
+
+func flattenPkgbuild(pkgbase string) error {
+	slog.Debug(fmt.Sprintf("Flattening PKGBUILD for pkgbase %s", pkgbase))
+
+	mergedPath := config.GetMergedPath(pkgbase)
+	pkgbuildPath := path.Join(mergedPath, "PKGBUILD")
+	pkgbuildBytes, err := os.ReadFile(pkgbuildPath)
+
+	if err != nil {
+		return err
+	}
+
+	flattened, err := flattenPkgbuildData(mergedPath, pkgbuildBytes)
+
+	if err != nil {
+		return err
+	}
+
+	if bytes.Equal(flattened, pkgbuildBytes) {
+		return nil
+	}
+
+	return os.WriteFile(pkgbuildPath, flattened, 0666)
+}
+
+// flattenPkgbuildData replaces the assignments of variables changed by the
+// appended overrides with their final values. If a changed variable is also
+// assigned somewhere that can't be replaced, such as inside a function or
+// through an index, the PKGBUILD is returned unchanged.
+func flattenPkgbuildData(dir string, pkgbuildBytes []byte) ([]byte, error) {
+	lines := strings.Split(string(pkgbuildBytes), "\n")
+	markerIndex := slices.Index(lines, appendMarker)
+
+	if markerIndex < 0 {
+		return pkgbuildBytes, nil
+	}
+
+	head := strings.Join(lines[:markerIndex], "\n")
+
+	oldPkgbuild, err := pacman.LoadPkgbuildData(dir, []byte(head))
+
+	if err != nil {
+		return nil, err
+	}
+
+	newPkgbuild, err := pacman.LoadPkgbuildData(dir, pkgbuildBytes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	oldVars := oldPkgbuild.Variables
+	newVars := newPkgbuild.Variables
+
+	var changed []string
+
+	for name, newVar := range newVars {
+		if oldVar := oldVars[name]; oldVar == nil || !isVariableEqual(oldVar, newVar) {
+			changed = append(changed, name)
+		}
+	}
+
+	for name := range oldVars {
+		if newVars[name] == nil {
+			changed = append(changed, name)
+		}
+	}
+
+	slices.Sort(changed)
+
+	assignments, unsupported, err := getPkgbuildAssignments(head)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range changed {
+		if unsupported[name] {
+			slog.Warn(fmt.Sprintf("Not flattening PKGBUILD in %s, %s is assigned outside of a plain top-level assignment", dir, name))
+			return pkgbuildBytes, nil
+		}
+	}
+
+	headLines := lines[:markerIndex]
+	replacements := map[int]string{}
+	removals := map[int]bool{}
+	var inserts []string
+
+	for _, name := range changed {
+		newVar := newVars[name]
+		varAssignments := assignments[name]
+
+		for _, assignment := range varAssignments {
+			for line := assignment.StartLine; line <= assignment.EndLine; line++ {
+				removals[line] = true
+			}
+		}
+
+		if newVar == nil {
+			continue
+		}
+
+		var originalWords map[string]string
+
+		if len(varAssignments) == 1 && !varAssignments[0].Append {
+			originalWords = mapOriginalWords(oldVars[name], varAssignments[0].Words)
+		}
+
+		literal, err := getVariableLiteral(name, newVar, originalWords)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(varAssignments) > 0 {
+			replacements[varAssignments[0].StartLine] = literal
+		} else {
+			inserts = append(inserts, literal)
+		}
+	}
+
+	insertAfter := -1
+
+	for _, name := range []string{"pkgrel", "pkgver", "pkgname", "pkgbase"} {
+		if varAssignments := assignments[name]; len(varAssignments) > 0 {
+			insertAfter = varAssignments[len(varAssignments)-1].EndLine
+			break
+		}
+	}
+
+	var result []string
+
+	if insertAfter < 0 {
+		result = append(result, inserts...)
+	}
+
+	for index, line := range headLines {
+		if replacement, ok := replacements[index]; ok {
+			result = append(result, replacement)
+		} else if !removals[index] {
+			result = append(result, line)
+		}
+
+		if index == insertAfter {
+			result = append(result, inserts...)
+		}
+	}
+
+	return []byte(strings.Join(result, "\n") + "\n"), nil
+}
+
+func isVariableEqual(variable *pacman.PkgbuildVariable, other *pacman.PkgbuildVariable) bool {
+	return variable.IsArray == other.IsArray && slices.Equal(variable.Values, other.Values)
+}
+
+func getVariableLiteral(name string, variable *pacman.PkgbuildVariable, originalWords map[string]string) (string, error) {
+	var words []string
+
+	for _, value := range variable.Values {
+		if word, ok := originalWords[value]; ok {
+			words = append(words, word)
+			continue
+		}
+
+		word, err := syntax.Quote(value, syntax.LangBash)
+
+		if err != nil {
+			return "", err
+		}
+
+		words = append(words, word)
+	}
+
+	if variable.IsArray {
+		return fmt.Sprintf("%s=(%s)", name, strings.Join(words, " ")), nil
+	}
+
+	if len(words) == 0 {
+		return fmt.Sprintf("%s=", name), nil
+	}
+
+	return fmt.Sprintf("%s=%s", name, words[0]), nil
+}
+
+func mapOriginalWords(variable *pacman.PkgbuildVariable, words []string) map[string]string {
+	if variable == nil || !variable.IsArray || len(variable.Values) != len(words) {
+		return nil
+	}
+
+	result := map[string]string{}
+
+	for index, value := range variable.Values {
+		result[value] = words[index]
+	}
+
+	return result
+}
+
+// getPkgbuildAssignments returns the plain top-level assignments of each
+// variable, along with the variables that are also assigned in any other way.
+func getPkgbuildAssignments(pkgbuild string) (map[string][]*pkgbuildAssignment, map[string]bool, error) {
+	parser := syntax.NewParser(
+		syntax.KeepComments(false),
+		syntax.Variant(syntax.LangBash),
+	)
+	printer := syntax.NewPrinter()
+
+	file, err := parser.Parse(strings.NewReader(pkgbuild), "PKGBUILD")
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	result := map[string][]*pkgbuildAssignment{}
+	handled := map[*syntax.Assign]bool{}
+
+	for _, stmt := range file.Stmts {
+		call, ok := stmt.Cmd.(*syntax.CallExpr)
+
+		if !ok || len(call.Args) > 0 || len(call.Assigns) != 1 {
+			continue
+		}
+
+		assign := call.Assigns[0]
+
+		if assign.Name == nil || assign.Index != nil {
+			continue
+		}
+
+		assignment := &pkgbuildAssignment{
+			StartLine: int(stmt.Pos().Line()) - 1,
+			EndLine:   int(stmt.End().Line()) - 1,
+			Append:    assign.Append,
+		}
+
+		if assign.Array != nil {
+			for _, elem := range assign.Array.Elems {
+				if elem.Index != nil {
+					assignment.Words = nil
+					break
+				}
+
+				buf := bytes.Buffer{}
+
+				if err := printer.Print(&buf, elem.Value); err != nil {
+					return nil, nil, err
+				}
+
+				assignment.Words = append(assignment.Words, buf.String())
+			}
+		}
+
+		handled[assign] = true
+		result[assign.Name.Value] = append(result[assign.Name.Value], assignment)
+	}
+
+	unsupported := map[string]bool{}
+
+	syntax.Walk(file, func(node syntax.Node) bool {
+		switch node := node.(type) {
+		case *syntax.Assign:
+			if node.Name != nil && !handled[node] {
+				unsupported[node.Name.Value] = true
+			}
+
+		case *syntax.WordIter:
+			unsupported[node.Name.Value] = true
+		}
+
+		return true
+	})
+
+	return result, unsupported, nil
+}
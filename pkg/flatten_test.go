@@ -0,0 +1,97 @@
+package pkg
+
+import (
+	"testing"
+)
+
+func TestFlattenPkgbuild(t *testing.T) {
+	header := "pkgname=foo\npkgver=1.0\npkgrel=1\n"
+
+	tests := []struct {
+		name     string
+		pkgbuild string
+		expected string // empty if the PKGBUILD must not be flattened
+	}{
+		{
+			name:     "no overrides",
+			pkgbuild: header + "depends=(a)\n",
+			expected: header + "depends=(a)\n",
+		},
+		{
+			name:     "replaced assignment",
+			pkgbuild: header + "depends=('a' \"b\")\n\n" + appendMarker + "\ndepends+=(c)\n",
+			expected: header + "depends=('a' \"b\" c)\n\n",
+		},
+		{
+			name:     "new variable",
+			pkgbuild: header + "\n" + appendMarker + "\nconflicts=(bar)\n",
+			expected: header + "conflicts=(bar)\n\n",
+		},
+		{
+			name:     "multiple assignments",
+			pkgbuild: header + "depends=(a)\ndepends+=(b)\n\n" + appendMarker + "\ndepends+=(c)\n",
+			expected: header + "depends=(a b c)\n\n",
+		},
+		{"assigned in if", header + "depends=(a)\nif true; then\n  depends=(b)\nfi\n\n" + appendMarker + "\ndepends+=(c)\n", ""},
+		{"assigned in case", header + "depends=(a)\ncase $CARCH in\n  x86_64) depends=(b) ;;\nesac\n\n" + appendMarker + "\ndepends+=(c)\n", ""},
+		{"assigned in function", header + "depends=(a)\n_setup() {\n  depends=(b)\n}\n_setup\n\n" + appendMarker + "\ndepends+=(c)\n", ""},
+		{"indexed assignment", header + "depends=(a)\ndepends[1]=b\n\n" + appendMarker + "\ndepends+=(c)\n", ""},
+		{"indexed append", header + "depends=(a)\ndepends[0]+=-git\n\n" + appendMarker + "\ndepends+=(c)\n", ""},
+		{"declared", header + "declare -a depends=(a)\n\n" + appendMarker + "\ndepends+=(c)\n", ""},
+		{"multiple in one statement", header + "depends=(a) makedepends=(b)\n\n" + appendMarker + "\ndepends+=(c)\n", ""},
+		{"loop variable", header + "depends=(a)\nfor depends in b; do :; done\n\n" + appendMarker + "\ndepends+=(c)\n", ""},
+		{
+			name:     "unchanged variable in function",
+			pkgbuild: header + "depends=(a)\nbuild() {\n  makedepends=(x)\n}\n\n" + appendMarker + "\ndepends+=(c)\n",
+			expected: header + "depends=(a c)\nbuild() {\n  makedepends=(x)\n}\n\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := flattenPkgbuildData(t.TempDir(), []byte(test.pkgbuild))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected := test.expected
+
+			if expected == "" {
+				expected = test.pkgbuild
+			}
+
+			if string(result) != expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", expected, result)
+			}
+		})
+	}
+}
+
+func TestGetPkgbuildAssignments(t *testing.T) {
+	assignments, unsupported, err := getPkgbuildAssignments("pkgname=foo\ndepends=(a 'b')\nsource+=(x)\nif true; then\n  arch=(any)\nfi\nlicense[0]=MIT\n")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if depends := assignments["depends"]; len(depends) != 1 || depends[0].StartLine != 1 || len(depends[0].Words) != 2 || depends[0].Words[1] != "'b'" {
+		t.Errorf("unexpected depends assignments %+v", depends)
+	}
+
+	if source := assignments["source"]; len(source) != 1 || !source[0].Append {
+		t.Errorf("expected an appending source assignment, got %+v", source)
+	}
+
+	for _, name := range []string{"arch", "license"} {
+		if !unsupported[name] || len(assignments[name]) > 0 {
+			t.Errorf("expected %s to be unsupported", name)
+		}
+	}
+
+	for _, name := range []string{"pkgname", "depends", "source"} {
+		if unsupported[name] {
+			t.Errorf("expected %s to be supported", name)
+		}
+	}
+}
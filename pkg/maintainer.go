@@ -0,0 +1,35 @@
+package pkg
+
+import "fmt"
+
+// CheckAurMaintainer reports whether maintainer differs from the AUR
+// maintainer last recorded for the package. isPending reports whether the
+// change is already waiting to be recorded, such as in an open pull request,
+// so that each change is only reported once.
+func (pconfig *PackageConfig) CheckAurMaintainer(maintainer string, isPending func() (bool, error)) (bool, error) {
+	if maintainer == pconfig.AurMaintainer {
+		return false, nil
+	}
+
+	pending, err := isPending()
+
+	if err != nil {
+		return false, err
+	}
+
+	return !pending, nil
+}
+
+// GetAurMaintainerMessage describes a change of AUR maintainer, or returns an
+// empty string when no maintainer was recorded before.
+func (pconfig *PackageConfig) GetAurMaintainerMessage(pkgbase string, maintainer string) string {
+	if pconfig.AurMaintainer == "" {
+		return ""
+	}
+
+	if maintainer == "" {
+		return fmt.Sprintf("AUR package %s was orphaned by %s", pkgbase, pconfig.AurMaintainer)
+	}
+
+	return fmt.Sprintf("AUR maintainer of package %s changed from %s to %s", pkgbase, pconfig.AurMaintainer, maintainer)
+}
@@ -0,0 +1,70 @@
+package pkg
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCheckAurMaintainer(t *testing.T) {
+	pconfig := &PackageConfig{AurMaintainer: "alice"}
+	var pending []string
+
+	check := func(maintainer string) bool {
+		t.Helper()
+
+		changed, err := pconfig.CheckAurMaintainer(maintainer, func() (bool, error) {
+			return slices.Contains(pending, maintainer), nil
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return changed
+	}
+
+	if check("alice") {
+		t.Error("expected no change for the recorded maintainer")
+	}
+
+	if !check("bob") {
+		t.Error("expected a change to a new maintainer")
+	}
+
+	pending = append(pending, "bob")
+
+	if check("bob") {
+		t.Error("expected a pending change not to be reported again")
+	}
+
+	if !check("") {
+		t.Error("expected orphaning to be reported while another change is pending")
+	}
+
+	pconfig.AurMaintainer = "bob"
+	pending = nil
+
+	if check("bob") {
+		t.Error("expected no change once the maintainer is recorded")
+	}
+}
+
+func TestGetAurMaintainerMessage(t *testing.T) {
+	tests := []struct {
+		previous   string
+		maintainer string
+		expected   string
+	}{
+		{"", "alice", ""},
+		{"alice", "bob", "AUR maintainer of package foo changed from alice to bob"},
+		{"alice", "", "AUR package foo was orphaned by alice"},
+	}
+
+	for _, test := range tests {
+		pconfig := &PackageConfig{AurMaintainer: test.previous}
+
+		if message := pconfig.GetAurMaintainerMessage("foo", test.maintainer); message != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, message)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package pkg
 
 import (
+	"errors"
 	"fmt"
 	"github.com/ryanpetris/aur-builder/config"
+	"github.com/ryanpetris/aur-builder/pacman"
 	"log/slog"
 	"os"
 	"os/exec"
@@ -33,6 +35,47 @@ func (pconfig *PackageConfig) ClearMerge(pkgbase string) error {
 	return nil
 }
 
+// SaveMerge moves the merged folder of a package aside so it can be merged
+// again, and returns a function that puts it back in place of whatever was
+// merged since.
+func SaveMerge(pkgbase string) (func() error, error) {
+	mergedPath := config.GetMergedPath(pkgbase)
+	savedPath := ""
+
+	if _, err := os.Stat(mergedPath); err == nil {
+		saveDir, err := os.MkdirTemp(config.GetPackagePath(pkgbase), ".merged-")
+
+		if err != nil {
+			return nil, err
+		}
+
+		savedPath = path.Join(saveDir, "merged")
+
+		if err := os.Rename(mergedPath, savedPath); err != nil {
+			_ = os.RemoveAll(saveDir)
+			return nil, err
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	return func() error {
+		if err := os.RemoveAll(mergedPath); err != nil {
+			return err
+		}
+
+		if savedPath == "" {
+			return nil
+		}
+
+		if err := os.Rename(savedPath, mergedPath); err != nil {
+			return err
+		}
+
+		return os.RemoveAll(path.Dir(savedPath))
+	}, nil
+}
+
 func (pconfig *PackageConfig) Merge(pkgbase string, processVcs bool) error {
 	slog.Debug(fmt.Sprintf("Merging %s", pkgbase))
 
@@ -107,6 +150,12 @@ func (pconfig *PackageConfig) Merge(pkgbase string, processVcs bool) error {
 		}
 	}
 
+	if pconfig.ShouldFlattenPkgbuild() {
+		if err := flattenPkgbuild(pkgbase); err != nil {
+			return err
+		}
+	}
+
 	if _, err := os.Stat(onmergeScriptPath); err == nil {
 		cmd := exec.Command(onmergeScriptPath)
 		cmd.Dir = mergedPath
@@ -120,5 +169,11 @@ func (pconfig *PackageConfig) Merge(pkgbase string, processVcs bool) error {
 		return err
 	}
 
-	return nil
+	pkginfo, err := pacman.LoadPkgInfo(pkgbase)
+
+	if err != nil {
+		return err
+	}
+
+	return pkginfo.SaveSrcinfo(path.Join(mergedPath, ".SRCINFO"))
 }
@@ -0,0 +1,88 @@
+package pkg
+
+import (
+	"github.com/ryanpetris/aur-builder/config"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestSaveMerge(t *testing.T) {
+	cfg := config.GetGlobalConfig()
+	oldBasePath := cfg.BasePath
+	cfg.BasePath = t.TempDir()
+
+	t.Cleanup(func() {
+		cfg.BasePath = oldBasePath
+	})
+
+	for _, prepared := range []bool{true, false} {
+		pkgbase := "unprepared"
+
+		if prepared {
+			pkgbase = "prepared"
+		}
+
+		t.Run(pkgbase, func(t *testing.T) {
+			mergedPath := config.GetMergedPath(pkgbase)
+
+			if err := os.MkdirAll(config.GetPackagePath(pkgbase), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			if prepared {
+				writeTestFile(t, mergedPath, "prepared")
+			}
+
+			restore, err := SaveMerge(pkgbase)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := os.Stat(mergedPath); !os.IsNotExist(err) {
+				t.Fatalf("expected the merged folder to be moved aside, got %v", err)
+			}
+
+			writeTestFile(t, mergedPath, "repository")
+
+			if err := restore(); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(path.Join(mergedPath, "PKGBUILD"))
+
+			if prepared {
+				if err != nil || string(data) != "prepared" {
+					t.Errorf("expected the prepared merged folder to be restored, got %q (%v)", data, err)
+				}
+			} else if !os.IsNotExist(err) {
+				t.Errorf("expected no merged folder, got %q (%v)", data, err)
+			}
+
+			entries, err := os.ReadDir(config.GetPackagePath(pkgbase))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, entry := range entries {
+				if entry.Name() != "merged" {
+					t.Errorf("unexpected leftover %s", entry.Name())
+				}
+			}
+		})
+	}
+}
+
+func writeTestFile(t *testing.T, dir string, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path.Join(dir, "PKGBUILD"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,15 +1,14 @@
 package pkg
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"github.com/ryanpetris/aur-builder/config"
 	"github.com/ryanpetris/aur-builder/misc"
 	"github.com/ryanpetris/aur-builder/pacman"
 	"log/slog"
+	"mvdan.cc/sh/v3/syntax"
 	"os"
-	"os/exec"
 	"path"
 	"regexp"
 	"slices"
@@ -18,25 +17,54 @@ import (
 	"text/template"
 )
 
+var dependsArrayRegex = regexp.MustCompile(`^(depends|makedepends|checkdepends|optdepends|provides|conflicts)(_[a-zA-Z0-9_]+)?$`)
+
+type pkgbuildEdit struct {
+	Start int
+	End   int
+	Text  string
+}
+
 func (pconfig *PackageConfig) ProcessOverrides(pkgbase string) error {
 	slog.Debug(fmt.Sprintf("Processing overrides for pkgbase %s", pkgbase))
 
-	if pconfig.Overrides == nil {
-		return nil
+	overrides := pconfig.Overrides
+	pconfig.UnmatchedOverrides = nil
+
+	if overrides == nil {
+		overrides = &PackageConfigOverrides{}
 	}
 
 	// First run functions that manipulate the PKGBUILD
 
-	if pconfig.Overrides.RenamePackage != nil {
-		err := processRenamePackage(pkgbase, pconfig.Overrides.RenamePackage)
+	if overrides.RemovePackage != nil {
+		err := processRemovePackage(pconfig, pkgbase, overrides.RemovePackage)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if overrides.RenamePackage != nil {
+		err := processRenamePackage(pconfig, pkgbase, overrides.RenamePackage)
 
 		if err != nil {
 			return err
 		}
 	}
 
-	if pconfig.Overrides.ModifySection != nil {
-		err := processModifySection(pkgbase, pconfig.Overrides.ModifySection)
+	if overrides.ModifySection != nil {
+		err := processModifySection(pconfig, pkgbase, overrides.ModifySection)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if replaceDependency, err := pconfig.GetReplaceDependency(pkgbase); err != nil {
+		return err
+	} else if len(replaceDependency) > 0 {
+		err := processReplaceDependency(pkgbase, replaceDependency)
 
 		if err != nil {
 			return err
@@ -45,7 +73,7 @@ func (pconfig *PackageConfig) ProcessOverrides(pkgbase string) error {
 
 	// Then run functions that merely append to the PKGBUILD
 
-	if pconfig.Overrides.BumpEpoch > 0 {
+	if overrides.BumpEpoch > 0 {
 		err := processBumpEpoch(pconfig, pkgbase)
 
 		if err != nil {
@@ -53,7 +81,7 @@ func (pconfig *PackageConfig) ProcessOverrides(pkgbase string) error {
 		}
 	}
 
-	if pconfig.Overrides.BumpPkgrel != nil {
+	if overrides.BumpPkgrel != nil {
 		err := processBumpPkgrel(pconfig, pkgbase)
 
 		if err != nil {
@@ -61,7 +89,7 @@ func (pconfig *PackageConfig) ProcessOverrides(pkgbase string) error {
 		}
 	}
 
-	if pconfig.Overrides.ClearDependsVersions {
+	if overrides.ClearDependsVersions {
 		err := processClearDependsVersions(pkgbase)
 
 		if err != nil {
@@ -69,8 +97,8 @@ func (pconfig *PackageConfig) ProcessOverrides(pkgbase string) error {
 		}
 	}
 
-	if pconfig.Overrides.ClearSignatures || pconfig.Overrides.RemoveSource != nil {
-		err := processRemoveSources(pkgbase, pconfig.Overrides)
+	if overrides.ClearSignatures || overrides.RemoveSource != nil {
+		err := processRemoveSources(pconfig, pkgbase, overrides)
 
 		if err != nil {
 			return err
@@ -79,16 +107,16 @@ func (pconfig *PackageConfig) ProcessOverrides(pkgbase string) error {
 
 	// Then run functions that don't touch the PKGBUILD at all
 
-	if pconfig.Overrides.DeleteFile != nil {
-		err := processDeleteFile(pkgbase, pconfig.Overrides.DeleteFile)
+	if overrides.DeleteFile != nil {
+		err := processDeleteFile(pconfig, pkgbase, overrides.DeleteFile)
 
 		if err != nil {
 			return err
 		}
 	}
 
-	if pconfig.Overrides.RenameFile != nil {
-		err := processRenameFile(pkgbase, pconfig.Overrides.RenameFile)
+	if overrides.RenameFile != nil {
+		err := processRenameFile(pkgbase, overrides.RenameFile)
 
 		if err != nil {
 			return err
@@ -143,7 +171,7 @@ func (pconfig *PackageConfig) ProcessVcsOverrides(pkgbase string) error {
 		},
 	}
 
-	err := processModifySection(pkgbase, modifySections)
+	err := processModifySection(pconfig, pkgbase, modifySections)
 
 	if err != nil {
 		return err
@@ -164,19 +192,11 @@ epoch=$((epoch + {{ .Bump }}))
 		return err
 	}
 
-	mergedPath := config.GetMergedPath(pkgbase)
-	pkgbuildPath := path.Join(mergedPath, "PKGBUILD")
-	pkgbuild, err := os.OpenFile(pkgbuildPath, os.O_APPEND|os.O_WRONLY, 0666)
-
-	if err != nil {
-		return err
-	}
-
-	defer pkgbuild.Close()
-
 	slog.Debug(fmt.Sprintf("Adding %d to epoch for pkgbase %s", pconfig.Overrides.BumpEpoch, pkgbase))
 
-	err = tmpl.Execute(pkgbuild, map[string]string{
+	appendText := strings.Builder{}
+
+	err = tmpl.Execute(&appendText, map[string]string{
 		"Bump": strconv.Itoa(pconfig.Overrides.BumpEpoch),
 	})
 
@@ -184,7 +204,7 @@ epoch=$((epoch + {{ .Bump }}))
 		return err
 	}
 
-	return nil
+	return appendPkgbuild(pkgbase, appendText.String())
 }
 
 func processBumpPkgrel(pconfig *PackageConfig, pkgbase string) error {
@@ -192,43 +212,30 @@ func processBumpPkgrel(pconfig *PackageConfig, pkgbase string) error {
 
 	pkgverFunc := `
 _bump_pkgrel() {
-    local pkgrel_parts
-    local new_pkgrel
-    local new_subpkgrel
-
-    local bump_parts
-    local bump_pkgrel
-    local bump_subpkgrel
+    local new_pkgrel="${pkgrel%%.*}"
+    local new_subpkgrel="0"
+    local bump_pkgrel="${1%%.*}"
+    local bump_subpkgrel="0"
 
-    IFS='.' read -a pkgrel_parts <<< "$1"
-
-    new_pkgrel="${pkgrel_parts[0]}"
-    new_subpkgrel="${pkgrel_parts[1]}"
-
-    if [ -z "$new_subpkgrel" ]; then
-        new_subpkgrel="0"
+    if [[ "$pkgrel" == *.* ]]; then
+        new_subpkgrel="${pkgrel#*.}"
     fi
 
-    IFS='.' read -a bump_parts <<< "$2"
-
-    bump_pkgrel="${bump_parts[0]}"
-    bump_subpkgrel="${bump_parts[1]}"
+    if [[ "$1" == *.* ]]; then
+        bump_subpkgrel="${1#*.}"
+    fi
 
     if [ -z "$bump_pkgrel" ]; then
         bump_pkgrel="0"
     fi
 
-    if [ -z "$bump_subpkgrel" ]; then
-        bump_subpkgrel="0"
-    fi
-
     new_pkgrel="$(($new_pkgrel + $bump_pkgrel))"
     new_subpkgrel="$(($new_subpkgrel + $bump_subpkgrel))"
 
     if [ "$new_subpkgrel" -gt 0 ]; then
-        echo "${new_pkgrel}.${new_subpkgrel}"
+        pkgrel="${new_pkgrel}.${new_subpkgrel}"
     else
-        echo "$new_pkgrel"
+        pkgrel="$new_pkgrel"
     fi
 }
 `
@@ -236,7 +243,7 @@ _bump_pkgrel() {
 	tmpl := template.New("t")
 	tmpl, err := tmpl.Parse(`
 if [ "$pkgver" = "{{ .Version }}" ]; then
-    pkgrel="$(_bump_pkgrel "$pkgrel" "{{ .Bump }}")"
+    _bump_pkgrel "{{ .Bump }}"
 fi
 `)
 
@@ -244,24 +251,13 @@ fi
 		return err
 	}
 
-	mergedPath := config.GetMergedPath(pkgbase)
-	pkgbuildPath := path.Join(mergedPath, "PKGBUILD")
-	pkgbuild, err := os.OpenFile(pkgbuildPath, os.O_APPEND|os.O_WRONLY, 0666)
-
-	if err != nil {
-		return err
-	}
-
-	if _, err := pkgbuild.WriteString(pkgverFunc); err != nil {
-		return err
-	}
-
-	defer pkgbuild.Close()
+	appendText := strings.Builder{}
+	appendText.WriteString(pkgverFunc)
 
 	for version, bump := range pconfig.Overrides.BumpPkgrel {
 		slog.Debug(fmt.Sprintf("Adding %d to pkgrel for pkgbase %s version %s", bump, pkgbase, version))
 
-		err = tmpl.Execute(pkgbuild, map[string]string{
+		err = tmpl.Execute(&appendText, map[string]string{
 			"Version": version,
 			"Bump":    strconv.Itoa(bump),
 		})
@@ -271,18 +267,33 @@ fi
 		}
 	}
 
-	return nil
+	return appendPkgbuild(pkgbase, appendText.String())
 }
 
 func processClearDependsVersions(pkgbase string) error {
 	slog.Debug(fmt.Sprintf("Processing clear depends versions override for pkgbase %s", pkgbase))
 
-	appendText := `mapfile -t depends < <((IFS=$'\n'; echo "${depends[*]}") | sed -E 's/[<>=].*$//' | sort | uniq)`
+	appendText := `
+_depends=()
+
+for _depend in "${depends[@]}"; do
+    _depend="${_depend%%[<>=]*}"
+
+    if [[ " ${_depends[*]} " != *" ${_depend} "* ]]; then
+        _depends+=("$_depend")
+    fi
+done
+
+depends=("${_depends[@]}")
+
+unset _depends
+unset _depend
+`
 
 	return appendPkgbuild(pkgbase, appendText)
 }
 
-func processRemoveSources(pkgbase string, overrides *PackageConfigOverrides) error {
+func processRemoveSources(pconfig *PackageConfig, pkgbase string, overrides *PackageConfigOverrides) error {
 	slog.Debug(fmt.Sprintf("Processing remove sources override for pkgbase %s", pkgbase))
 
 	if overrides.ClearSignatures {
@@ -291,7 +302,9 @@ func processRemoveSources(pkgbase string, overrides *PackageConfigOverrides) err
 		}
 	}
 
-	return removeSource(pkgbase, func(val string) (bool, error) {
+	matchedSources := map[string]bool{}
+
+	err := removeSource(pkgbase, func(val string) (bool, error) {
 		parts := strings.SplitN(val, "::", 2)
 		filename := path.Base(parts[0])
 
@@ -308,6 +321,7 @@ func processRemoveSources(pkgbase string, overrides *PackageConfigOverrides) err
 				if matched, err := regexp.MatchString(source, filename); err != nil {
 					return false, err
 				} else if matched {
+					matchedSources[source] = true
 					return true, nil
 				}
 			}
@@ -315,9 +329,21 @@ func processRemoveSources(pkgbase string, overrides *PackageConfigOverrides) err
 
 		return false, nil
 	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, source := range overrides.RemoveSource {
+		if !matchedSources[source] {
+			pconfig.addUnmatchedOverride(fmt.Sprintf("removeSource %s", source))
+		}
+	}
+
+	return nil
 }
 
-func processDeleteFile(pkgbase string, files []string) error {
+func processDeleteFile(pconfig *PackageConfig, pkgbase string, files []string) error {
 	slog.Debug(fmt.Sprintf("Processing delete file override for pkgbase %s", pkgbase))
 
 	mergedPath := config.GetMergedPath(pkgbase)
@@ -325,6 +351,11 @@ func processDeleteFile(pkgbase string, files []string) error {
 	for _, item := range files {
 		filePath := path.Join(mergedPath, item)
 
+		if _, err := os.Lstat(filePath); errors.Is(err, os.ErrNotExist) {
+			pconfig.addUnmatchedOverride(fmt.Sprintf("deleteFile %s", item))
+			continue
+		}
+
 		if err := os.RemoveAll(filePath); err != nil {
 			return err
 		}
@@ -333,7 +364,7 @@ func processDeleteFile(pkgbase string, files []string) error {
 	return nil
 }
 
-func processModifySection(pkgbase string, overrides []*PackageConfigModifySection) error {
+func processModifySection(pconfig *PackageConfig, pkgbase string, overrides []*PackageConfigModifySection) error {
 	slog.Debug(fmt.Sprintf("Processing modify section overrides for pkgbase %s", pkgbase))
 
 	mergedPath := config.GetMergedPath(pkgbase)
@@ -445,6 +476,7 @@ func processModifySection(pkgbase string, overrides []*PackageConfigModifySectio
 					if !foundStart || !foundEnd {
 						if override.Type == "" {
 							if override.Append == "" && override.Prepend == "" {
+								pconfig.addUnmatchedOverride(fmt.Sprintf("modifySection %s", sectionName))
 								break
 							}
 
@@ -477,6 +509,10 @@ func processModifySection(pkgbase string, overrides []*PackageConfigModifySectio
 								return err
 							}
 
+							if !re.MatchString(sectionStr) {
+								pconfig.addUnmatchedOverride(fmt.Sprintf("modifySection %s replace %s", sectionName, item.From))
+							}
+
 							sectionStr = re.ReplaceAllString(sectionStr, item.To)
 						}
 
@@ -510,6 +546,10 @@ func processModifySection(pkgbase string, overrides []*PackageConfigModifySectio
 								return err
 							}
 
+							if !slices.ContainsFunc(sectionItems, re.MatchString) {
+								pconfig.addUnmatchedOverride(fmt.Sprintf("modifySection %s replace %s", sectionName, item.From))
+							}
+
 							for sItemIndex, sItem := range sectionItems {
 								sectionItems[sItemIndex] = re.ReplaceAllString(sItem, item.To)
 							}
@@ -540,6 +580,10 @@ func processModifySection(pkgbase string, overrides []*PackageConfigModifySectio
 								return err
 							}
 
+							if !re.MatchString(sectionValue) {
+								pconfig.addUnmatchedOverride(fmt.Sprintf("modifySection %s replace %s", sectionName, item.From))
+							}
+
 							sectionValue = re.ReplaceAllString(sectionValue, item.To)
 						}
 					}
@@ -562,6 +606,10 @@ func processModifySection(pkgbase string, overrides []*PackageConfigModifySectio
 								return err
 							}
 
+							if !re.MatchString(sectionStr) {
+								pconfig.addUnmatchedOverride(fmt.Sprintf("modifySection %s replace %s", sectionName, item.From))
+							}
+
 							sectionStr = re.ReplaceAllString(sectionStr, item.To)
 						}
 
@@ -607,7 +655,77 @@ func processRenameFile(pkgbase string, overrides []*PackageConfigOverrideFromTo)
 	return nil
 }
 
-func processRenamePackage(pkgbase string, overrides []*PackageConfigOverrideFromTo) error {
+func processRemovePackage(pconfig *PackageConfig, pkgbase string, removePackages []string) error {
+	slog.Debug(fmt.Sprintf("Processing remove package override for pkgbase %s", pkgbase))
+
+	packages, err := pacman.GetPkgbuildVars(pkgbase, "pkgname")
+
+	if err != nil {
+		return err
+	}
+
+	var pkgnames []string
+	var funcnames []string
+	replace := map[string]string{}
+	functypenames := []string{"package", "prepare", "build", "check"}
+
+	for _, pkgname := range packages {
+		if !slices.Contains(removePackages, pkgname) {
+			pkgnames = append(pkgnames, pkgname)
+			continue
+		}
+
+		replace[pkgname] = ""
+
+		for _, functypename := range functypenames {
+			funcnames = append(funcnames, fmt.Sprintf("%s_%s", functypename, pkgname))
+		}
+	}
+
+	for _, pkgname := range removePackages {
+		if !slices.Contains(packages, pkgname) {
+			pconfig.addUnmatchedOverride(fmt.Sprintf("removePackage %s", pkgname))
+		}
+	}
+
+	if len(pkgnames) == 0 {
+		return errors.New(fmt.Sprintf("cannot remove all packages from pkgbase %s", pkgbase))
+	}
+
+	if len(replace) == 0 {
+		return nil
+	}
+
+	mergedPath := config.GetMergedPath(pkgbase)
+	pkgbuildPath := path.Join(mergedPath, "PKGBUILD")
+	pkgbuildBytes, err := os.ReadFile(pkgbuildPath)
+
+	if err != nil {
+		return err
+	}
+
+	pkgbuild := string(pkgbuildBytes)
+
+	if result, err := replacePkgname(pkgbuild, pkgnames); err != nil {
+		return err
+	} else {
+		pkgbuild = result
+	}
+
+	if result, err := removeFunctions(pkgbuild, funcnames); err != nil {
+		return err
+	} else {
+		pkgbuild = result
+	}
+
+	if err := os.WriteFile(pkgbuildPath, []byte(pkgbuild), 0666); err != nil {
+		return err
+	}
+
+	return processReplaceDependency(pkgbase, replace)
+}
+
+func processRenamePackage(pconfig *PackageConfig, pkgbase string, overrides []*PackageConfigOverrideFromTo) error {
 	slog.Debug(fmt.Sprintf("Processing rename package override for pkgbase %s", pkgbase))
 
 	packages, err := pacman.GetPkgbuildVars(pkgbase, "pkgname")
@@ -616,6 +734,14 @@ func processRenamePackage(pkgbase string, overrides []*PackageConfigOverrideFrom
 		return err
 	}
 
+	for _, override := range overrides {
+		if override.From != "" && !slices.Contains(packages, override.From) {
+			pconfig.addUnmatchedOverride(fmt.Sprintf("renamePackage %s", override.From))
+		} else if override.From == "" && !slices.Contains(packages, pkgbase) {
+			pconfig.addUnmatchedOverride(fmt.Sprintf("renamePackage %s", pkgbase))
+		}
+	}
+
 	var pkgnames []string
 	namechangemap := map[string]string{}
 	functypenames := []string{"package", "prepare", "build", "check"}
@@ -672,9 +798,126 @@ func processRenamePackage(pkgbase string, overrides []*PackageConfigOverrideFrom
 	return nil
 }
 
+func processReplaceDependency(pkgbase string, replace map[string]string) error {
+	slog.Debug(fmt.Sprintf("Processing replace dependency overrides for pkgbase %s", pkgbase))
+
+	mergedPath := config.GetMergedPath(pkgbase)
+	pkgbuildPath := path.Join(mergedPath, "PKGBUILD")
+	pkgbuildBytes, err := os.ReadFile(pkgbuildPath)
+
+	if err != nil {
+		return err
+	}
+
+	pkgbuild, err := replaceDependencies(string(pkgbuildBytes), replace)
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(pkgbuildPath, []byte(pkgbuild), 0666); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func replaceDependencies(pkgbuild string, replace map[string]string) (string, error) {
+	file, err := parsePkgbuild(pkgbuild)
+
+	if err != nil {
+		return "", err
+	}
+
+	var edits []*pkgbuildEdit
+
+	syntax.Walk(file, func(node syntax.Node) bool {
+		assign, ok := node.(*syntax.Assign)
+
+		if !ok || assign.Name == nil || !dependsArrayRegex.MatchString(assign.Name.Value) {
+			return true
+		}
+
+		if assign.Array == nil {
+			if assign.Value == nil {
+				return true
+			}
+
+			start, end := int(assign.Value.Pos().Offset()), int(assign.Value.End().Offset())
+
+			if newItem, keep := replaceDependencyItem(pkgbuild[start:end], replace); !keep {
+				edits = append(edits, &pkgbuildEdit{Start: start, End: end, Text: "()"})
+			} else if newItem != pkgbuild[start:end] {
+				edits = append(edits, &pkgbuildEdit{Start: start, End: end, Text: newItem})
+			}
+
+			return true
+		}
+
+		for _, elem := range assign.Array.Elems {
+			if elem.Index != nil || elem.Value == nil {
+				continue
+			}
+
+			start, end := int(elem.Value.Pos().Offset()), int(elem.Value.End().Offset())
+
+			if newItem, keep := replaceDependencyItem(pkgbuild[start:end], replace); !keep {
+				edits = append(edits, getRemovalEdit(pkgbuild, start, end))
+			} else if newItem != pkgbuild[start:end] {
+				edits = append(edits, &pkgbuildEdit{Start: start, End: end, Text: newItem})
+			}
+		}
+
+		return true
+	})
+
+	return applyPkgbuildEdits(pkgbuild, edits), nil
+}
+
+func replaceDependencyItem(item string, replace map[string]string) (string, bool) {
+	quote := ""
+	value := item
+
+	if len(item) >= 2 && (item[0] == '"' || item[0] == '\'') && item[len(item)-1] == item[0] {
+		quote = item[:1]
+		value = item[1 : len(item)-1]
+	}
+
+	name := value
+	rest := ""
+
+	if index := strings.IndexAny(value, "<>=:"); index >= 0 {
+		name, rest = value[:index], value[index:]
+	}
+
+	to, found := replace[name]
+
+	if !found {
+		return item, true
+	}
+
+	if to == "" {
+		return "", false
+	}
+
+	return fmt.Sprintf("%s%s%s%s", quote, to, rest, quote), true
+}
+
+func (pconfig *PackageConfig) addUnmatchedOverride(override string) {
+	slog.Warn(fmt.Sprintf("Override %s no longer matches anything", override))
+
+	pconfig.UnmatchedOverrides = append(pconfig.UnmatchedOverrides, override)
+}
+
 func appendPkgbuild(pkgbase string, appendText string) error {
 	mergedPath := config.GetMergedPath(pkgbase)
 	pkgbuildPath := path.Join(mergedPath, "PKGBUILD")
+	pkgbuildBytes, err := os.ReadFile(pkgbuildPath)
+
+	if err != nil {
+		return err
+	}
+
 	pkgbuild, err := os.OpenFile(pkgbuildPath, os.O_APPEND|os.O_WRONLY, 0666)
 
 	if err != nil {
@@ -683,6 +926,12 @@ func appendPkgbuild(pkgbase string, appendText string) error {
 
 	defer pkgbuild.Close()
 
+	if !slices.Contains(strings.Split(string(pkgbuildBytes), "\n"), appendMarker) {
+		if _, err = pkgbuild.WriteString(fmt.Sprintf("\n%s\n", appendMarker)); err != nil {
+			return err
+		}
+	}
+
 	if _, err = pkgbuild.WriteString(fmt.Sprintf("\n%s\n", appendText)); err != nil {
 		return err
 	}
@@ -755,123 +1004,180 @@ func replaceFunctionNames(pkgbuild string, namechangemap map[string]string) (str
 	}), nil
 }
 
-func isSignature(value string) (bool, error) {
-	parts := strings.SplitN(value, "::", 2)
-	sigExtensions := []string{
-		".sig",
-		".sign",
-		".asc",
+func removeFunctions(pkgbuild string, funcnames []string) (string, error) {
+	file, err := parsePkgbuild(pkgbuild)
+
+	if err != nil {
+		return "", err
 	}
 
-	for _, ext := range sigExtensions {
-		if strings.HasSuffix(parts[0], ext) {
-			return true, nil
+	var edits []*pkgbuildEdit
+
+	for _, stmt := range file.Stmts {
+		decl, ok := stmt.Cmd.(*syntax.FuncDecl)
+
+		if !ok || !slices.Contains(funcnames, decl.Name.Value) {
+			continue
 		}
+
+		edits = append(edits, getRemovalEdit(pkgbuild, int(stmt.Pos().Offset()), int(stmt.End().Offset())))
 	}
 
-	return false, nil
+	return applyPkgbuildEdits(pkgbuild, edits), nil
 }
 
-func removeSource(pkgbase string, matcher func(string) (bool, error)) error {
-	slog.Debug(fmt.Sprintf("Removing source files for pkgbase %s", pkgbase))
+func parsePkgbuild(pkgbuild string) (*syntax.File, error) {
+	parser := syntax.NewParser(
+		syntax.KeepComments(false),
+		syntax.Variant(syntax.LangBash),
+	)
+
+	return parser.Parse(strings.NewReader(pkgbuild), "PKGBUILD")
+}
+
+func getRemovalEdit(pkgbuild string, start int, end int) *pkgbuildEdit {
+	lineStart := start
 
-	type arrayDef struct {
-		Name     string
-		OrigName string
-		Arch     string
-		Items    map[string]string
+	for lineStart > 0 && (pkgbuild[lineStart-1] == ' ' || pkgbuild[lineStart-1] == '\t') {
+		lineStart--
 	}
 
-	mergedPath := config.GetMergedPath(pkgbase)
-	pkgbuildPath := path.Join(mergedPath, "PKGBUILD")
-	var stdoutBuf = bytes.Buffer{}
+	lineEnd := end
 
-	cmd := exec.Command("bash", "-c", fmt.Sprintf(`source "%s"; declare -p | grep -E '^declare -a (source|[a-z0-9]+sums)(_[a-z0-9_]+)?='`, pkgbuildPath))
-	cmd.Stdout = &stdoutBuf
+	for lineEnd < len(pkgbuild) && (pkgbuild[lineEnd] == ' ' || pkgbuild[lineEnd] == '\t') {
+		lineEnd++
+	}
 
-	if err := cmd.Run(); err != nil {
-		return err
+	commentEnd := lineEnd
+
+	if commentEnd < len(pkgbuild) && pkgbuild[commentEnd] == '#' {
+		for commentEnd < len(pkgbuild) && pkgbuild[commentEnd] != '\n' {
+			commentEnd++
+		}
 	}
 
-	re, err := regexp.Compile(`\[(?P<index>[0-9]+)]="(?P<value>(?:[^\\"]|\\"|\\[^"])*?)"`)
+	if (lineStart == 0 || pkgbuild[lineStart-1] == '\n') && (commentEnd == len(pkgbuild) || pkgbuild[commentEnd] == '\n') {
+		return &pkgbuildEdit{Start: lineStart, End: min(commentEnd+1, len(pkgbuild))}
+	}
 
-	if err != nil {
-		return err
+	if lineStart < start {
+		return &pkgbuildEdit{Start: lineStart, End: end}
 	}
 
-	var parsedArrays []arrayDef
+	return &pkgbuildEdit{Start: start, End: lineEnd}
+}
+
+func applyPkgbuildEdits(pkgbuild string, edits []*pkgbuildEdit) string {
+	slices.SortFunc(edits, func(a *pkgbuildEdit, b *pkgbuildEdit) int {
+		return b.Start - a.Start
+	})
+
+	result := pkgbuild
+	limit := len(pkgbuild)
 
-	for _, line := range strings.Split(stdoutBuf.String(), "\n") {
-		if line == "" {
+	for _, edit := range edits {
+		end := min(edit.End, limit)
+
+		if edit.Start > end {
 			continue
 		}
 
-		declareParts := strings.SplitN(line, " ", 3)
-		varParts := strings.SplitN(declareParts[2], "=", 2)
-		nameParts := strings.SplitN(varParts[0], "_", 2)
+		result = result[:edit.Start] + edit.Text + result[end:]
+		limit = edit.Start
+	}
 
-		def := arrayDef{
-			Name:     nameParts[0],
-			OrigName: varParts[0],
-			Items:    map[string]string{},
-		}
+	return result
+}
 
-		if len(nameParts) > 1 {
-			def.Arch = nameParts[1]
+func isSignature(value string) (bool, error) {
+	parts := strings.SplitN(value, "::", 2)
+	sigExtensions := []string{
+		".sig",
+		".sign",
+		".asc",
+	}
+
+	for _, ext := range sigExtensions {
+		if strings.HasSuffix(parts[0], ext) {
+			return true, nil
 		}
+	}
 
-		matches := re.FindAllStringSubmatch(varParts[1], -1)
+	return false, nil
+}
 
-		for _, match := range matches {
-			matchMap := misc.RegexMapMatchByGroup(re, match)
+func removeSource(pkgbase string, matcher func(string) (bool, error)) error {
+	slog.Debug(fmt.Sprintf("Removing source files for pkgbase %s", pkgbase))
 
-			def.Items[matchMap["index"]] = matchMap["value"]
-		}
+	mergedPath := config.GetMergedPath(pkgbase)
+	pkgbuild, err := pacman.LoadPkgbuild(path.Join(mergedPath, "PKGBUILD"))
 
-		parsedArrays = append(parsedArrays, def)
+	if err != nil {
+		return err
 	}
 
-	var appendLines []string
-	var affectedArrays []string
+	removeIndexes := map[string][]int{}
 
-	for _, item := range parsedArrays {
-		if item.Name != "source" {
+	for name, variable := range pkgbuild.Variables {
+		if !variable.IsArray || (name != "source" && !strings.HasPrefix(name, "source_")) {
 			continue
 		}
 
-		for index, value := range item.Items {
+		arch := strings.TrimPrefix(strings.TrimPrefix(name, "source"), "_")
+
+		for index, value := range variable.Values {
 			if remove, err := matcher(value); err != nil {
 				return err
 			} else if !remove {
 				continue
 			}
 
-			if !slices.Contains(affectedArrays, item.OrigName) {
-				affectedArrays = append(affectedArrays, item.OrigName)
-			}
+			removeIndexes[arch] = append(removeIndexes[arch], index)
+		}
+	}
 
-			appendLines = append(appendLines, fmt.Sprintf("unset %s[%s]", item.OrigName, index))
+	var names []string
 
-			for _, sumItem := range parsedArrays {
-				if !strings.HasSuffix(sumItem.Name, "sums") {
-					continue
-				}
+	for name := range pkgbuild.Variables {
+		names = append(names, name)
+	}
 
-				if sumItem.Arch != item.Arch {
-					continue
-				}
+	slices.Sort(names)
 
-				if !slices.Contains(affectedArrays, sumItem.OrigName) {
-					affectedArrays = append(affectedArrays, sumItem.OrigName)
-				}
+	var appendLines []string
+
+	for _, name := range names {
+		variable := pkgbuild.Variables[name]
+		nameParts := strings.SplitN(name, "_", 2)
+		arch := ""
 
-				appendLines = append(appendLines, fmt.Sprintf("unset %s[%s]", sumItem.OrigName, index))
+		if len(nameParts) > 1 {
+			arch = nameParts[1]
+		}
+
+		if !variable.IsArray || (nameParts[0] != "source" && !strings.HasSuffix(nameParts[0], "sums")) {
+			continue
+		}
+
+		indexes := removeIndexes[arch]
+
+		if len(indexes) == 0 {
+			continue
+		}
+
+		var items []string
+
+		for index := range variable.Values {
+			if !slices.Contains(indexes, index) {
+				items = append(items, fmt.Sprintf(`"${%s[%d]}"`, name, index))
 			}
 		}
+
+		appendLines = append(appendLines, fmt.Sprintf("%s=(%s)", name, strings.Join(items, " ")))
 	}
 
-	for _, item := range affectedArrays {
-		appendLines = append(appendLines, fmt.Sprintf(`mapfile -t %s < <(IFS=$'\n'; echo "${%s[*]}")`, item, item))
+	if len(appendLines) == 0 {
+		return nil
 	}
 
 	appendText := strings.Join(appendLines, "\n")
@@ -913,36 +1219,51 @@ func splitVariableLine(line string) (string, string, error) {
 func processVcsSrcOverrides(pkgbase string, overrides []*PackageConfigOverrideFromTo) error {
 	slog.Debug(fmt.Sprintf("Processing vcs source overrides %s", pkgbase))
 
-	appendText := `
-mapfile -t _SOURCE_ARRAYS < <(compgen -v source)
+	mergedPath := config.GetMergedPath(pkgbase)
+	pkgbuild, err := pacman.LoadPkgbuild(path.Join(mergedPath, "PKGBUILD"))
 
-for _SOURCE_ARRAY in "${_SOURCE_ARRAYS[@]}"; do
-    for ((_SOURCE_INDEX = 0; _SOURCE_INDEX < $(eval echo '"'"\${#${_SOURCE_ARRAY}[*]}"'"'); _SOURCE_INDEX++)); do
-        _SOURCE_VALUE="$(eval echo '"'"\${${_SOURCE_ARRAY}[${_SOURCE_INDEX}]}"'"')"
-%s
-    done
-done
+	if err != nil {
+		return err
+	}
 
-unset _SOURCE_ARRAYS
-unset _SOURCE_ARRAY
-unset _SOURCE_INDEX
-unset _SOURCE_VALUE
-`
+	var names []string
 
-	ifText := `
+	for name := range pkgbuild.Variables {
+		names = append(names, name)
+	}
 
-        if [ "${_SOURCE_VALUE}" = "%s" ]; then
-            eval ${_SOURCE_ARRAY}[${_SOURCE_INDEX}]='"'"%s"'"'
-        fi
-`
+	slices.Sort(names)
 
-	ifSections := []string{}
+	var appendLines []string
 
-	for _, override := range overrides {
-		ifSections = append(ifSections, fmt.Sprintf(ifText, override.From, override.To))
+	for _, name := range names {
+		variable := pkgbuild.Variables[name]
+
+		if !variable.IsArray || !strings.HasPrefix(name, "source") {
+			continue
+		}
+
+		for index, value := range variable.Values {
+			for _, override := range overrides {
+				if value != override.From {
+					continue
+				}
+
+				quoted, err := syntax.Quote(override.To, syntax.LangBash)
+
+				if err != nil {
+					return err
+				}
+
+				appendLines = append(appendLines, fmt.Sprintf("%s[%d]=%s", name, index, quoted))
+				break
+			}
+		}
 	}
 
-	appendText = fmt.Sprintf(appendText, strings.Join(ifSections, "\n"))
+	if len(appendLines) == 0 {
+		return nil
+	}
 
-	return appendPkgbuild(pkgbase, appendText)
+	return appendPkgbuild(pkgbase, strings.Join(appendLines, "\n"))
 }
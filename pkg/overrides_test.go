@@ -0,0 +1,119 @@
+package pkg
+
+import (
+	"testing"
+)
+
+func TestReplaceDependencies(t *testing.T) {
+	replace := map[string]string{
+		"foo": "bar",
+		"old": "",
+	}
+
+	tests := []struct {
+		name     string
+		pkgbuild string
+		expected string
+	}{
+		{
+			name:     "single line",
+			pkgbuild: "depends=('foo' 'baz>=1' old)\n",
+			expected: "depends=('bar' 'baz>=1')\n",
+		},
+		{
+			name:     "multi line",
+			pkgbuild: "depends=(\n  'foo>=2'\n  'old'\n  'baz'\n)\n",
+			expected: "depends=(\n  'bar>=2'\n  'baz'\n)\n",
+		},
+		{
+			name:     "trailing comment",
+			pkgbuild: "makedepends=('old' \"foo\") # build only\n",
+			expected: "makedepends=(\"bar\") # build only\n",
+		},
+		{
+			name:     "comment inside array",
+			pkgbuild: "depends=(\n  foo # needed at runtime\n  old\n)\n",
+			expected: "depends=(\n  bar # needed at runtime\n)\n",
+		},
+		{
+			name:     "split package function",
+			pkgbuild: "package_a() {\n  depends+=(\n    'foo=1.0'\n  )\n  optdepends=('old: something' 'baz: other')\n}\n",
+			expected: "package_a() {\n  depends+=(\n    'bar=1.0'\n  )\n  optdepends=('baz: other')\n}\n",
+		},
+		{
+			name:     "arch specific",
+			pkgbuild: "depends_x86_64=('old' 'foo')\nprovides=(foo)\nconflicts=('old')\n",
+			expected: "depends_x86_64=('bar')\nprovides=(bar)\nconflicts=()\n",
+		},
+		{
+			name:     "unrelated arrays",
+			pkgbuild: "source=('foo' 'old')\ndepends=('foobar' 'baz')\n",
+			expected: "source=('foo' 'old')\ndepends=('foobar' 'baz')\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := replaceDependencies(test.pkgbuild, replace)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if result != test.expected {
+				t.Errorf("expected:\n%s\ngot:\n%s", test.expected, result)
+			}
+		})
+	}
+}
+
+func TestRemoveFunctions(t *testing.T) {
+	pkgbuild := `pkgname=(a b)
+
+package_a() {
+  if true; then
+    echo a
+  fi
+}
+
+package_b() {
+  cd "$srcdir"
+  {
+    echo b
+  } > out
+  } # end of package_b
+
+function build_b {
+  make
+}
+
+package_c() {
+  echo c
+}
+`
+
+	expected := `pkgname=(a b)
+
+package_a() {
+  if true; then
+    echo a
+  fi
+}
+
+
+
+package_c() {
+  echo c
+}
+`
+
+	result, err := removeFunctions(pkgbuild, []string{"package_b", "build_b"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, result)
+	}
+}
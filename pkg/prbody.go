@@ -0,0 +1,402 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/ryanpetris/aur-builder/config"
+	"github.com/ryanpetris/aur-builder/git"
+	"github.com/ryanpetris/aur-builder/pacman"
+	"github.com/sergi/go-diff/diffmatchpatch"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	prBodyDiffContext  = 2
+	prBodyMaxDiffLines = 200
+)
+
+var dependsFields = []string{"depends", "makedepends", "checkdepends", "optdepends"}
+
+type PackageSnapshot struct {
+	Version        string
+	UpstreamCommit string
+	UpstreamFiles  map[string]string
+	Pkgbuild       string
+	Depends        []string
+}
+
+func TakePackageSnapshot(pkgbase string, processVcs bool) (*PackageSnapshot, error) {
+	pconfig, err := LoadConfig(pkgbase)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pconfig.Merge(pkgbase, processVcs); err != nil {
+		return nil, err
+	}
+
+	snapshot, err := pconfig.ReadPackageSnapshot(pkgbase)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := pconfig.ClearMerge(pkgbase); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+func (pconfig *PackageConfig) ReadPackageSnapshot(pkgbase string) (*PackageSnapshot, error) {
+	mergedPath := config.GetMergedPath(pkgbase)
+	pkgbuildBytes, err := os.ReadFile(path.Join(mergedPath, "PKGBUILD"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	pkginfo, err := pacman.LoadSrcinfo(path.Join(mergedPath, ".SRCINFO"))
+
+	if err != nil {
+		return nil, err
+	}
+
+	upstreamFiles, err := readUpstreamFiles(pkgbase)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &PackageSnapshot{
+		Version:        pkginfo.GetFullVersion(),
+		UpstreamCommit: pconfig.UpstreamCommit,
+		UpstreamFiles:  upstreamFiles,
+		Pkgbuild:       string(pkgbuildBytes),
+		Depends:        getSnapshotDepends(pkginfo),
+	}, nil
+}
+
+func (pconfig *PackageConfig) GeneratePrBody(pkgbase string, before *PackageSnapshot) (string, error) {
+	after, err := pconfig.ReadPackageSnapshot(pkgbase)
+
+	if err != nil {
+		return "", err
+	}
+
+	body := strings.Builder{}
+
+	if before == nil {
+		body.WriteString(fmt.Sprintf("- **Version:** `%s`\n", after.Version))
+	} else {
+		body.WriteString(fmt.Sprintf("- **Version:** `%s` → `%s`\n", before.Version, after.Version))
+	}
+
+	switch pconfig.Source {
+	case "aur":
+		body.WriteString(fmt.Sprintf("- **Source:** [AUR](%s)\n", config.GetAurPackagePageUrl(pkgbase)))
+	case "arch":
+		body.WriteString(fmt.Sprintf("- **Source:** [Arch Linux](%s)\n", config.GetArchPackagePageUrl(pkgbase)))
+	case "":
+	default:
+		body.WriteString(fmt.Sprintf("- **Source:** %s\n", pconfig.Source))
+	}
+
+	if len(pconfig.UnmatchedOverrides) > 0 {
+		body.WriteString("\n### Overrides no longer matching\n\n")
+
+		for _, override := range pconfig.UnmatchedOverrides {
+			body.WriteString(fmt.Sprintf("- `%s`\n", override))
+		}
+	}
+
+	if before == nil {
+		return body.String(), nil
+	}
+
+	if upstreamLog := pconfig.getUpstreamLog(pkgbase, before.UpstreamCommit, after.UpstreamCommit); len(upstreamLog) > 0 {
+		body.WriteString("\n### Upstream commits\n\n")
+
+		for _, line := range upstreamLog {
+			body.WriteString(fmt.Sprintf("- %s\n", line))
+		}
+	}
+
+	if dependsChanges := getDependsChanges(before.Depends, after.Depends); len(dependsChanges) > 0 {
+		body.WriteString("\n### Dependency changes\n\n")
+
+		for _, line := range dependsChanges {
+			body.WriteString(fmt.Sprintf("- %s\n", line))
+		}
+	}
+
+	if upstreamChanges := getUpstreamChanges(before.UpstreamFiles, after.UpstreamFiles); len(upstreamChanges) > 0 {
+		body.WriteString("\n### Upstream changes\n\n")
+
+		for _, line := range upstreamChanges {
+			body.WriteString(fmt.Sprintf("- %s\n", line))
+		}
+	}
+
+	if pkgbuildDiff := getSummaryDiff(before.Pkgbuild, after.Pkgbuild); pkgbuildDiff != "" {
+		body.WriteString("\n### Merged PKGBUILD changes\n\n")
+		body.WriteString(fmt.Sprintf("```diff\n%s```\n", pkgbuildDiff))
+	}
+
+	return body.String(), nil
+}
+
+func (pconfig *PackageConfig) getUpstreamLog(pkgbase string, from string, to string) []string {
+	if from == "" || to == "" || from == to {
+		return nil
+	}
+
+	var url string
+
+	switch pconfig.Source {
+	case "aur":
+		url = config.GetAurPackageGitUrl(pkgbase)
+	case "arch":
+		url = config.GetArchPackageGitUrl(pkgbase)
+	default:
+		return nil
+	}
+
+	result, err := git.GetUpstreamLog(url, from, to)
+
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Could not get upstream commit log for pkgbase %s: %s", pkgbase, err))
+		return nil
+	}
+
+	return result
+}
+
+func readUpstreamFiles(pkgbase string) (map[string]string, error) {
+	upstreamPath := config.GetUpstreamPath(pkgbase)
+	result := map[string]string{}
+
+	if _, err := os.Stat(upstreamPath); errors.Is(err, os.ErrNotExist) {
+		return result, nil
+	}
+
+	err := filepath.WalkDir(upstreamPath, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		relPath, err := filepath.Rel(upstreamPath, filePath)
+
+		if err != nil {
+			return err
+		}
+
+		content, err := os.ReadFile(filePath)
+
+		if err != nil {
+			return err
+		}
+
+		result[filepath.ToSlash(relPath)] = string(content)
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func getSnapshotDepends(pkginfo *pacman.PkgInfo) []string {
+	var result []string
+
+	fields := map[string][]pacman.PkgInfoArchItem{
+		"depends":      pkginfo.Depends,
+		"makedepends":  pkginfo.MakeDepends,
+		"checkdepends": pkginfo.CheckDepends,
+		"optdepends":   pkginfo.OptDepends,
+	}
+
+	for field, items := range fields {
+		for _, item := range items {
+			name := field
+
+			if item.Arch != "" {
+				name = fmt.Sprintf("%s_%s", field, item.Arch)
+			}
+
+			result = append(result, fmt.Sprintf("`%s`: `%s`", name, item.Value))
+		}
+	}
+
+	for _, pkg := range pkginfo.Packages {
+		for name, values := range pkg.Overrides {
+			field := strings.SplitN(name, "_", 2)[0]
+
+			if !slices.Contains(dependsFields, field) {
+				continue
+			}
+
+			for _, value := range values {
+				result = append(result, fmt.Sprintf("`%s` (%s): `%s`", name, pkg.Pkgname, value))
+			}
+		}
+	}
+
+	slices.Sort(result)
+
+	return slices.Compact(result)
+}
+
+func getDependsChanges(before []string, after []string) []string {
+	var result []string
+
+	for _, item := range before {
+		if !slices.Contains(after, item) {
+			result = append(result, fmt.Sprintf("Removed %s", item))
+		}
+	}
+
+	for _, item := range after {
+		if !slices.Contains(before, item) {
+			result = append(result, fmt.Sprintf("Added %s", item))
+		}
+	}
+
+	return result
+}
+
+func getUpstreamChanges(before map[string]string, after map[string]string) []string {
+	var names []string
+
+	for name := range before {
+		names = append(names, name)
+	}
+
+	for name := range after {
+		if _, ok := before[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	slices.Sort(names)
+
+	var result []string
+
+	for _, name := range names {
+		beforeContent, inBefore := before[name]
+		afterContent, inAfter := after[name]
+
+		if !inBefore {
+			result = append(result, fmt.Sprintf("`%s` added", name))
+		} else if !inAfter {
+			result = append(result, fmt.Sprintf("`%s` removed", name))
+		} else if beforeContent != afterContent {
+			if isBinaryContent(beforeContent) || isBinaryContent(afterContent) {
+				result = append(result, fmt.Sprintf("`%s` modified (binary)", name))
+				continue
+			}
+
+			added, removed := 0, 0
+
+			for _, item := range diff.Do(beforeContent, afterContent) {
+				lines := strings.Count(item.Text, "\n")
+
+				if !strings.HasSuffix(item.Text, "\n") {
+					lines += 1
+				}
+
+				switch item.Type {
+				case diffmatchpatch.DiffInsert:
+					added += lines
+				case diffmatchpatch.DiffDelete:
+					removed += lines
+				}
+			}
+
+			result = append(result, fmt.Sprintf("`%s` modified (+%d -%d)", name, added, removed))
+		}
+	}
+
+	return result
+}
+
+func getSummaryDiff(before string, after string) string {
+	if before == after {
+		return ""
+	}
+
+	type diffLine struct {
+		prefix string
+		text   string
+	}
+
+	var lines []diffLine
+
+	for _, item := range diff.Do(before, after) {
+		prefix := " "
+
+		switch item.Type {
+		case diffmatchpatch.DiffInsert:
+			prefix = "+"
+		case diffmatchpatch.DiffDelete:
+			prefix = "-"
+		}
+
+		for _, line := range strings.Split(strings.TrimSuffix(item.Text, "\n"), "\n") {
+			lines = append(lines, diffLine{prefix, line})
+		}
+	}
+
+	show := make([]bool, len(lines))
+
+	for index, line := range lines {
+		if line.prefix == " " {
+			continue
+		}
+
+		for offset := max(0, index-prBodyDiffContext); offset <= min(len(lines)-1, index+prBodyDiffContext); offset++ {
+			show[offset] = true
+		}
+	}
+
+	result := strings.Builder{}
+	written := 0
+	skipped := false
+
+	for index, line := range lines {
+		if !show[index] {
+			skipped = true
+			continue
+		}
+
+		if written >= prBodyMaxDiffLines {
+			result.WriteString("... (truncated)\n")
+			break
+		}
+
+		if skipped {
+			result.WriteString("@@\n")
+		}
+
+		result.WriteString(fmt.Sprintf("%s%s\n", line.prefix, line.text))
+		written += 1
+		skipped = false
+	}
+
+	return result.String()
+}
+
+func isBinaryContent(content string) bool {
+	return strings.IndexByte(content, 0) >= 0
+}
@@ -0,0 +1,92 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ryanpetris/aur-builder/config"
+	"reflect"
+	"slices"
+)
+
+func (pconfig *PackageConfig) GetRepositories() []string {
+	if len(pconfig.Repositories) == 0 {
+		return config.GetDefaultRepositories()
+	}
+
+	var result []string
+
+	for _, repository := range pconfig.Repositories {
+		result = append(result, repository.Name)
+	}
+
+	return result
+}
+
+func (pconfig *PackageConfig) InRepository(name string) bool {
+	return slices.Contains(pconfig.GetRepositories(), name)
+}
+
+func (pconfig *PackageConfig) ForRepository(pkgbase string, name string) (*PackageConfig, error) {
+	if config.GetRepository(name) == nil {
+		return nil, errors.New(fmt.Sprintf("repository %s for pkgbase %s is not configured", name, pkgbase))
+	}
+
+	if !pconfig.InRepository(name) {
+		return nil, errors.New(fmt.Sprintf("pkgbase %s is not in repository %s", pkgbase, name))
+	}
+
+	result := *pconfig
+
+	for _, repository := range pconfig.Repositories {
+		if repository.Name == name && repository.Overrides != nil {
+			result.Overrides = mergeOverrides(pconfig.Overrides, repository.Overrides)
+		}
+	}
+
+	return &result, nil
+}
+
+func mergeOverrides(base *PackageConfigOverrides, extra *PackageConfigOverrides) *PackageConfigOverrides {
+	result := &PackageConfigOverrides{}
+
+	if base != nil {
+		*result = *base
+	}
+
+	resultValue := reflect.ValueOf(result).Elem()
+	extraValue := reflect.ValueOf(extra).Elem()
+
+	for index := 0; index < resultValue.NumField(); index++ {
+		field := resultValue.Field(index)
+		extraField := extraValue.Field(index)
+
+		if extraField.IsZero() {
+			continue
+		}
+
+		switch field.Kind() {
+		case reflect.Slice:
+			merged := reflect.MakeSlice(field.Type(), 0, field.Len()+extraField.Len())
+			merged = reflect.AppendSlice(merged, field)
+			field.Set(reflect.AppendSlice(merged, extraField))
+
+		case reflect.Map:
+			merged := reflect.MakeMap(field.Type())
+
+			for _, source := range []reflect.Value{field, extraField} {
+				iter := source.MapRange()
+
+				for iter.Next() {
+					merged.SetMapIndex(iter.Key(), iter.Value())
+				}
+			}
+
+			field.Set(merged)
+
+		default:
+			field.Set(extraField)
+		}
+	}
+
+	return result
+}
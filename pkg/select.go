@@ -0,0 +1,234 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ryanpetris/aur-builder/config"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+var selectFields = []string{"pkgbase", "source", "tag", "repository", "maintainer", "vcs", "ignore"}
+
+type PackageSelector struct {
+	Packages []string
+	Tags     []string
+	Sources  []string
+	Excludes []string
+	Filters  []string
+}
+
+type packageFilterTerm struct {
+	Field  string
+	Value  string
+	Negate bool
+}
+
+func (selector *PackageSelector) IsEmpty() bool {
+	return len(selector.Packages) == 0 && len(selector.Tags) == 0 && len(selector.Sources) == 0 && len(selector.Excludes) == 0 && len(selector.Filters) == 0
+}
+
+func (selector *PackageSelector) IsExplicit(pkgbase string) bool {
+	return slices.Contains(selector.Packages, pkgbase)
+}
+
+func (selector *PackageSelector) Select() ([]string, error) {
+	filters := map[string][]*packageFilterTerm{}
+
+	for _, filter := range selector.Filters {
+		terms, err := parsePackageFilter(filter)
+
+		if err != nil {
+			return nil, err
+		}
+
+		filters[filter] = terms
+	}
+
+	entries, err := os.ReadDir(config.GetBasePath())
+
+	if err != nil {
+		return nil, err
+	}
+
+	matched := map[string]bool{}
+	var result []string
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		pkgbase := entry.Name()
+		pconfig, err := LoadConfig(pkgbase)
+
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("unable to load config for pkgbase %s: %s", pkgbase, err))
+		}
+
+		if pconfig.Ignore && !selector.IsExplicit(pkgbase) {
+			continue
+		}
+
+		selected := true
+
+		checks := []struct {
+			flag     string
+			patterns []string
+			values   []string
+		}{
+			{"--package", selector.Packages, []string{pkgbase}},
+			{"--tag", selector.Tags, pconfig.Tags},
+			{"--source", selector.Sources, []string{pconfig.Source}},
+		}
+
+		for _, check := range checks {
+			if len(check.patterns) == 0 {
+				continue
+			}
+
+			found := false
+
+			for _, pattern := range check.patterns {
+				if matchAnyPattern(pattern, check.values) {
+					matched[fmt.Sprintf("%s %s", check.flag, pattern)] = true
+					found = true
+				}
+			}
+
+			selected = selected && found
+		}
+
+		if len(filters) > 0 {
+			found := false
+
+			for filter, terms := range filters {
+				if pconfig.matchesFilter(pkgbase, terms) {
+					matched[fmt.Sprintf("--select %s", filter)] = true
+					found = true
+				}
+			}
+
+			selected = selected && found
+		}
+
+		for _, pattern := range selector.Excludes {
+			if matchAnyPattern(pattern, []string{pkgbase}) {
+				matched[fmt.Sprintf("--exclude %s", pattern)] = true
+				selected = false
+			}
+		}
+
+		if selected {
+			result = append(result, pkgbase)
+		}
+	}
+
+	for _, item := range selector.getSelectorNames() {
+		if !matched[item] {
+			return nil, errors.New(fmt.Sprintf("%s does not match any packages", item))
+		}
+	}
+
+	if len(result) == 0 && !selector.IsEmpty() {
+		return nil, errors.New("the selection does not match any packages")
+	}
+
+	return result, nil
+}
+
+func (selector *PackageSelector) getSelectorNames() []string {
+	var result []string
+
+	for flag, values := range map[string][]string{
+		"--package": selector.Packages,
+		"--tag":     selector.Tags,
+		"--source":  selector.Sources,
+		"--select":  selector.Filters,
+		"--exclude": selector.Excludes,
+	} {
+		for _, value := range values {
+			result = append(result, fmt.Sprintf("%s %s", flag, value))
+		}
+	}
+
+	slices.Sort(result)
+
+	return result
+}
+
+func (pconfig *PackageConfig) matchesFilter(pkgbase string, terms []*packageFilterTerm) bool {
+	for _, term := range terms {
+		var values []string
+
+		switch term.Field {
+		case "pkgbase":
+			values = []string{pkgbase}
+		case "source":
+			values = []string{pconfig.Source}
+		case "tag":
+			values = pconfig.Tags
+		case "repository":
+			values = pconfig.GetRepositories()
+		case "maintainer":
+			values = []string{pconfig.AurMaintainer}
+		case "vcs":
+			values = []string{fmt.Sprintf("%t", pconfig.Vcs != nil)}
+		case "ignore":
+			values = []string{fmt.Sprintf("%t", pconfig.Ignore)}
+		}
+
+		if matchAnyPattern(term.Value, values) == term.Negate {
+			return false
+		}
+	}
+
+	return true
+}
+
+func parsePackageFilter(filter string) ([]*packageFilterTerm, error) {
+	var result []*packageFilterTerm
+
+	for _, item := range strings.Split(filter, ",") {
+		item = strings.TrimSpace(item)
+		term := &packageFilterTerm{}
+
+		if strings.HasPrefix(item, "!") {
+			term.Negate = true
+			item = item[1:]
+		}
+
+		field, value, ok := strings.Cut(item, "=")
+
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("invalid selection %s: expected field=value, got %s", filter, item))
+		}
+
+		term.Field = strings.TrimSpace(field)
+		term.Value = strings.TrimSpace(value)
+
+		if !slices.Contains(selectFields, term.Field) {
+			return nil, errors.New(fmt.Sprintf("invalid selection %s: unknown field %s, expected one of %s", filter, term.Field, strings.Join(selectFields, ", ")))
+		}
+
+		if _, err := path.Match(term.Value, ""); err != nil {
+			return nil, errors.New(fmt.Sprintf("invalid selection %s: invalid pattern %s", filter, term.Value))
+		}
+
+		result = append(result, term)
+	}
+
+	return result, nil
+}
+
+func matchAnyPattern(pattern string, values []string) bool {
+	for _, value := range values {
+		if matched, _ := path.Match(pattern, value); matched {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,193 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ryanpetris/aur-builder/pacman"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	UpdateAllowMajor = "major"
+	UpdateAllowMinor = "minor"
+	UpdateAllowPatch = "patch"
+)
+
+var versionComponentRegex = regexp.MustCompile(`[0-9]+|[a-zA-Z]+`)
+
+func (pconfig *PackageConfig) GetHold() (*PackageUpdateHold, bool, error) {
+	if pconfig.UpdatePolicy == nil || pconfig.UpdatePolicy.Hold == nil {
+		return nil, false, nil
+	}
+
+	hold := pconfig.UpdatePolicy.Hold
+
+	if hold.Until == "" {
+		return hold, true, nil
+	}
+
+	until, err := hold.GetUntil()
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return hold, time.Now().Before(until), nil
+}
+
+// GetUntil returns the time the hold expires. A date without a time holds
+// through the end of that day in UTC, so the start of the next day is returned.
+func (hold *PackageUpdateHold) GetUntil() (time.Time, error) {
+	if until, err := time.Parse(time.DateOnly, hold.Until); err == nil {
+		return until.AddDate(0, 0, 1), nil
+	}
+
+	until, err := time.Parse(time.RFC3339, hold.Until)
+
+	if err != nil {
+		return time.Time{}, errors.New(fmt.Sprintf("invalid hold expiry %s, expected a date such as 2006-01-02", hold.Until))
+	}
+
+	return until, nil
+}
+
+func (hold *PackageUpdateHold) String() string {
+	result := "held"
+
+	if hold.Version != "" {
+		result = fmt.Sprintf("held at %s", hold.Version)
+	}
+
+	if hold.Until != "" {
+		result = fmt.Sprintf("%s until %s", result, hold.Until)
+	}
+
+	if hold.Reason != "" {
+		result = fmt.Sprintf("%s: %s", result, hold.Reason)
+	}
+
+	return result
+}
+
+func (pconfig *PackageConfig) CheckUpdatePolicy(oldVersion string, newVersion string, getReleaseTime func() (time.Time, error)) (bool, string, error) {
+	if pconfig.UpdatePolicy == nil {
+		return true, "", nil
+	}
+
+	policy := pconfig.UpdatePolicy
+
+	if hold, active, err := pconfig.GetHold(); err != nil {
+		return false, "", err
+	} else if active {
+		if hold.Version == "" {
+			return false, hold.String(), nil
+		}
+
+		if newer, err := pacman.IsVersionNewer(hold.Version, newVersion); err != nil {
+			return false, "", err
+		} else if newer {
+			return false, hold.String(), nil
+		}
+	}
+
+	newParsed, err := pacman.ParseVersion(newVersion)
+
+	if err != nil {
+		return false, "", err
+	}
+
+	for _, pattern := range policy.IgnoreVersions {
+		re, err := regexp.Compile(pattern)
+
+		if err != nil {
+			return false, "", errors.New(fmt.Sprintf("invalid ignoreVersions pattern %s: %s", pattern, err))
+		}
+
+		if re.MatchString(newParsed.Pkgver) {
+			return false, fmt.Sprintf("version %s matches ignored pattern %s", newParsed.Pkgver, pattern), nil
+		}
+	}
+
+	if policy.Allow != "" && oldVersion != "" {
+		oldParsed, err := pacman.ParseVersion(oldVersion)
+
+		if err != nil {
+			return false, "", err
+		}
+
+		if allowed, err := isUpdateAllowed(policy.Allow, oldParsed, newParsed); err != nil {
+			return false, "", err
+		} else if !allowed {
+			return false, fmt.Sprintf("%s to %s is not a %s update", oldParsed.Pkgver, newParsed.Pkgver, policy.Allow), nil
+		}
+	}
+
+	if policy.MinAge != "" {
+		minAge, err := parsePolicyDuration(policy.MinAge)
+
+		if err != nil {
+			return false, "", err
+		}
+
+		releaseTime, err := getReleaseTime()
+
+		if err != nil {
+			return false, fmt.Sprintf("release time unknown: %s", err), nil
+		}
+
+		if allowedAt := releaseTime.Add(minAge); time.Now().Before(allowedAt) {
+			return false, fmt.Sprintf("released %s, update allowed after %s", releaseTime.UTC().Format(time.RFC3339), allowedAt.UTC().Format(time.RFC3339)), nil
+		}
+	}
+
+	return true, "", nil
+}
+
+func isUpdateAllowed(allow string, oldVersion *pacman.Version, newVersion *pacman.Version) (bool, error) {
+	var fixed int
+
+	switch allow {
+	case UpdateAllowMajor:
+		return true, nil
+	case UpdateAllowMinor:
+		fixed = 1
+	case UpdateAllowPatch:
+		fixed = 2
+	default:
+		return false, errors.New(fmt.Sprintf("invalid allow value %s, expected one of major, minor, patch", allow))
+	}
+
+	if oldVersion.GetEpoch() != newVersion.GetEpoch() {
+		return false, nil
+	}
+
+	oldComponents := getVersionComponents(oldVersion.Pkgver)
+	newComponents := getVersionComponents(newVersion.Pkgver)
+
+	for index := 0; index < fixed; index++ {
+		if getVersionComponent(oldComponents, index) != getVersionComponent(newComponents, index) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func getVersionComponents(pkgver string) []string {
+	return versionComponentRegex.FindAllString(pkgver, -1)
+}
+
+func getVersionComponent(components []string, index int) string {
+	if index >= len(components) {
+		return "0"
+	}
+
+	if value, err := strconv.Atoi(components[index]); err == nil {
+		return strconv.Itoa(value)
+	}
+
+	return strings.ToLower(components[index])
+}
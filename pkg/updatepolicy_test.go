@@ -0,0 +1,185 @@
+package pkg
+
+import (
+	"errors"
+	"github.com/ryanpetris/aur-builder/pacman"
+	"testing"
+	"time"
+)
+
+func TestIsUpdateAllowed(t *testing.T) {
+	tests := []struct {
+		allow      string
+		oldVersion string
+		newVersion string
+		expected   bool
+	}{
+		{UpdateAllowMajor, "1.2.3-1", "2.0.0-1", true},
+		{UpdateAllowMinor, "1.2.3-1", "1.3.0-1", true},
+		{UpdateAllowMinor, "1.2.3-1", "2.0.0-1", false},
+		{UpdateAllowPatch, "1.2.3-1", "1.2.4-1", true},
+		{UpdateAllowPatch, "1.2.3-1", "1.3.0-1", false},
+		{UpdateAllowPatch, "1.2-1", "1.2.1-1", true},
+		{UpdateAllowPatch, "1.2-1", "1.2-2", true},
+		{UpdateAllowMinor, "1.02-1", "1.2.5-1", true},
+		{UpdateAllowMinor, "1.2rc1-1", "1.2-1", true},
+		{UpdateAllowMinor, "v1.RC-1", "V1.rc.1-1", true},
+		{UpdateAllowMinor, "1:1.2-1", "2:1.2-1", false},
+		{UpdateAllowMajor, "1:1.2-1", "2:1.2-1", true},
+		{UpdateAllowMinor, "1.2-1", "0:1.2.1-1", true},
+		{UpdateAllowMinor, "x:1.2-1", "1.2.1-1", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.allow+" "+test.oldVersion+" "+test.newVersion, func(t *testing.T) {
+			oldVersion, err := pacman.ParseVersion(test.oldVersion)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			newVersion, err := pacman.ParseVersion(test.newVersion)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			allowed, err := isUpdateAllowed(test.allow, oldVersion, newVersion)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if allowed != test.expected {
+				t.Errorf("expected %t, got %t", test.expected, allowed)
+			}
+		})
+	}
+
+	if _, err := isUpdateAllowed("minor-ish", &pacman.Version{}, &pacman.Version{}); err == nil {
+		t.Error("expected an error for an invalid allow value")
+	}
+}
+
+func TestGetHold(t *testing.T) {
+	now := time.Now().UTC()
+
+	tests := []struct {
+		name     string
+		until    string
+		expected bool
+		invalid  bool
+	}{
+		{name: "no expiry", until: "", expected: true},
+		{name: "today", until: now.Format(time.DateOnly), expected: true},
+		{name: "tomorrow", until: now.AddDate(0, 0, 1).Format(time.DateOnly), expected: true},
+		{name: "yesterday", until: now.AddDate(0, 0, -1).Format(time.DateOnly), expected: false},
+		{name: "future time", until: now.Add(time.Hour).Format(time.RFC3339), expected: true},
+		{name: "past time", until: now.Add(-time.Hour).Format(time.RFC3339), expected: false},
+		{name: "invalid", until: "next week", invalid: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pconfig := &PackageConfig{UpdatePolicy: &PackageUpdatePolicy{Hold: &PackageUpdateHold{Until: test.until}}}
+			_, active, err := pconfig.GetHold()
+
+			if test.invalid {
+				if err == nil {
+					t.Error("expected an error")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if active != test.expected {
+				t.Errorf("expected active %t, got %t", test.expected, active)
+			}
+		})
+	}
+
+	if _, active, err := (&PackageConfig{}).GetHold(); err != nil || active {
+		t.Errorf("expected no hold without an update policy, got %t (%v)", active, err)
+	}
+}
+
+func TestCheckUpdatePolicy(t *testing.T) {
+	releasedAt := func(age time.Duration) func() (time.Time, error) {
+		return func() (time.Time, error) {
+			return time.Now().Add(-age), nil
+		}
+	}
+
+	unknown := func() (time.Time, error) {
+		return time.Time{}, errors.New("not found")
+	}
+
+	tests := []struct {
+		name        string
+		policy      *PackageUpdatePolicy
+		oldVersion  string
+		newVersion  string
+		releaseTime func() (time.Time, error)
+		expected    bool
+	}{
+		{name: "no policy", newVersion: "2.0-1", expected: true},
+		{name: "hold", policy: &PackageUpdatePolicy{Hold: &PackageUpdateHold{}}, oldVersion: "1.0-1", newVersion: "1.1-1", expected: false},
+		{name: "hold below version", policy: &PackageUpdatePolicy{Hold: &PackageUpdateHold{Version: "1.1"}}, oldVersion: "1.0-1", newVersion: "1.1-1", expected: true},
+		{name: "hold above version", policy: &PackageUpdatePolicy{Hold: &PackageUpdateHold{Version: "1.1"}}, oldVersion: "1.0-1", newVersion: "1.2-1", expected: false},
+		{name: "expired hold", policy: &PackageUpdatePolicy{Hold: &PackageUpdateHold{Until: "2000-01-01"}}, oldVersion: "1.0-1", newVersion: "1.2-1", expected: true},
+		{name: "ignored version", policy: &PackageUpdatePolicy{IgnoreVersions: []string{`rc[0-9]+$`}}, oldVersion: "1.0-1", newVersion: "1.1rc1-1", expected: false},
+		{name: "allowed minor", policy: &PackageUpdatePolicy{Allow: UpdateAllowMinor}, oldVersion: "1.0-1", newVersion: "1.1-1", expected: true},
+		{name: "disallowed major", policy: &PackageUpdatePolicy{Allow: UpdateAllowMinor}, oldVersion: "1.0-1", newVersion: "2.0-1", expected: false},
+		{name: "non-numeric epoch", policy: &PackageUpdatePolicy{Allow: UpdateAllowPatch}, oldVersion: "x:1.0-1", newVersion: "x:1.0.1-1", expected: true},
+		{name: "allow new package", policy: &PackageUpdatePolicy{Allow: UpdateAllowPatch}, newVersion: "2.0-1", expected: true},
+		{name: "old enough", policy: &PackageUpdatePolicy{MinAge: "3d"}, oldVersion: "1.0-1", newVersion: "1.1-1", releaseTime: releasedAt(4 * 24 * time.Hour), expected: true},
+		{name: "too new", policy: &PackageUpdatePolicy{MinAge: "3d"}, oldVersion: "1.0-1", newVersion: "1.1-1", releaseTime: releasedAt(2 * 24 * time.Hour), expected: false},
+		{name: "too new hours", policy: &PackageUpdatePolicy{MinAge: "12h"}, oldVersion: "1.0-1", newVersion: "1.1-1", releaseTime: releasedAt(time.Hour), expected: false},
+		{name: "release time unknown", policy: &PackageUpdatePolicy{MinAge: "12h"}, oldVersion: "1.0-1", newVersion: "1.1-1", releaseTime: unknown, expected: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pconfig := &PackageConfig{UpdatePolicy: test.policy}
+			releaseTime := test.releaseTime
+
+			if releaseTime == nil {
+				releaseTime = func() (time.Time, error) {
+					t.Error("release time should not be needed")
+					return time.Time{}, nil
+				}
+			}
+
+			allowed, reason, err := pconfig.CheckUpdatePolicy(test.oldVersion, test.newVersion, releaseTime)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if allowed != test.expected {
+				t.Errorf("expected allowed %t, got %t (%s)", test.expected, allowed, reason)
+			}
+
+			if !allowed && reason == "" {
+				t.Error("expected a reason when the update is not allowed")
+			}
+		})
+	}
+
+	invalid := []*PackageUpdatePolicy{
+		{Allow: "sometimes"},
+		{MinAge: "soon"},
+		{IgnoreVersions: []string{"("}},
+		{Hold: &PackageUpdateHold{Until: "06/01/2025"}},
+	}
+
+	for _, policy := range invalid {
+		if _, _, err := (&PackageConfig{UpdatePolicy: policy}).CheckUpdatePolicy("1.0-1", "1.1-1", unknown); err == nil {
+			t.Errorf("expected an error for policy %+v", policy)
+		}
+	}
+}
@@ -9,10 +9,7 @@ import (
 	"log/slog"
 	"os"
 	"path"
-)
-
-const (
-	fragmentTypeCommit = "commit"
+	"regexp"
 )
 
 func (pconfig *PackageConfig) GenVcsInfo(pkgbase string) (bool, error) {
@@ -39,7 +36,7 @@ func (pconfig *PackageConfig) GenVcsInfo(pkgbase string) (bool, error) {
 				return false, err
 			}
 
-			if source.VcsType != "git" {
+			if !source.IsVcs() {
 				continue
 			}
 
@@ -51,6 +48,16 @@ func (pconfig *PackageConfig) GenVcsInfo(pkgbase string) (bool, error) {
 		return false, nil
 	}
 
+	if pconfig.Vcs != nil && !pconfig.hasVcsSourceChanges(vcsSources) {
+		slog.Debug(fmt.Sprintf("No VCS source changes found for pkgbase %s", pkgbase))
+
+		return false, nil
+	}
+
+	if err := prepareVcsCache(pkgbase, vcsSources); err != nil {
+		return false, err
+	}
+
 	if err := pacman.DownloadSources(pkgbase); err != nil {
 		return false, err
 	}
@@ -62,6 +69,29 @@ func (pconfig *PackageConfig) GenVcsInfo(pkgbase string) (bool, error) {
 		return false, err
 	}
 
+	if pconfig.Vcs != nil {
+		for folder, pattern := range pconfig.Vcs.FollowTags {
+			source := vcsSources[folder]
+
+			if source == nil || source.VcsType != pacman.VcsTypeGit {
+				return false, errors.New(fmt.Sprintf("followTags source %s is not a git source", folder))
+			}
+
+			tag, commit, err := getFollowedTag(source, pattern)
+
+			if err != nil {
+				return false, err
+			}
+
+			if err := git.CheckoutRevision(path.Join(sourcesPath, folder), commit); err != nil {
+				return false, err
+			}
+
+			source.FragmentType = pacman.FragmentTypeTag
+			source.FragmentValue = tag
+		}
+	}
+
 	vcsPkgver, vcsPkgrel, vcsSubPkgrel, err := GetMergedVcsPkgver(pkgbase)
 
 	if err != nil {
@@ -77,7 +107,10 @@ func (pconfig *PackageConfig) GenVcsInfo(pkgbase string) (bool, error) {
 	}
 
 	if pconfig.Vcs != nil {
+		vcinfo.LastBump = pconfig.Vcs.LastBump
 		vcinfo.Submodules = pconfig.Vcs.Submodules
+		vcinfo.FollowTags = pconfig.Vcs.FollowTags
+		vcinfo.Policy = pconfig.Vcs.Policy
 	}
 
 	if vcinfo.Submodules != nil {
@@ -88,7 +121,7 @@ func (pconfig *PackageConfig) GenVcsInfo(pkgbase string) (bool, error) {
 
 			source := vcsSources[srcPath.Name()]
 
-			if source == nil {
+			if source == nil || source.VcsType != pacman.VcsTypeGit {
 				continue
 			}
 
@@ -113,12 +146,11 @@ func (pconfig *PackageConfig) GenVcsInfo(pkgbase string) (bool, error) {
 			for sourceName, targetName := range submoduleMap {
 				targetSource := vcsSources[targetName]
 
-				if targetSource != nil && targetSource.FragmentType != fragmentTypeCommit {
+				if targetSource != nil && targetSource.VcsType == pacman.VcsTypeGit && !targetSource.IsPinned() {
 					submodule := submodules[sourceName]
 
 					if submodule != nil {
-						targetSource.FragmentType = fragmentTypeCommit
-						targetSource.FragmentValue = submodule.Hash
+						targetSource.Pin(submodule.Hash)
 					}
 				}
 			}
@@ -136,15 +168,26 @@ func (pconfig *PackageConfig) GenVcsInfo(pkgbase string) (bool, error) {
 			continue
 		}
 
-		if source.FragmentType != fragmentTypeCommit {
-			revision, err := git.GetRevision(path.Join(sourcesPath, srcPath.Name()))
+		if !source.IsPinned() {
+			revision, err := getSourceRevision(source, path.Join(sourcesPath, srcPath.Name()))
 
 			if err != nil {
 				return false, err
 			}
 
-			source.FragmentType = fragmentTypeCommit
-			source.FragmentValue = revision
+			if source.FragmentType == pacman.FragmentTypeTag {
+				vcinfo.Tags = append(vcinfo.Tags, &PackageVcsTag{
+					Source: srcPath.Name(),
+					Tag:    source.FragmentValue,
+					Commit: revision,
+				})
+
+				if moved := pconfig.getMovedTag(srcPath.Name(), source.FragmentValue, revision); moved != "" {
+					vcinfo.MovedTags = append(vcinfo.MovedTags, moved)
+				}
+			}
+
+			source.Pin(revision)
 		}
 
 		override := &PackageConfigOverrideFromTo{
@@ -186,3 +229,128 @@ func (pconfig *PackageConfig) GenVcsInfo(pkgbase string) (bool, error) {
 
 	return false, nil
 }
+
+func getSourceRevision(source *pacman.Source, dir string) (string, error) {
+	if source.VcsType == pacman.VcsTypeGit {
+		return git.GetRevision(dir)
+	}
+
+	return pacman.GetVcsRevision(source.VcsType, dir)
+}
+
+func (pconfig *PackageConfig) hasVcsSourceChanges(vcsSources map[string]*pacman.Source) bool {
+	for folder, source := range vcsSources {
+		if source.IsPinned() {
+			continue
+		}
+
+		if _, ok := pconfig.Vcs.Submodules[folder]; ok {
+			continue
+		}
+
+		if source.VcsType != pacman.VcsTypeGit {
+			return true
+		}
+
+		followPattern, followTag := pconfig.Vcs.FollowTags[folder]
+
+		var pinned *pacman.Source
+
+		for _, override := range pconfig.Vcs.SourceOverrides {
+			if override.From == source.Original {
+				pinned, _ = pacman.ParseSource(override.To)
+				break
+			}
+		}
+
+		if pinned == nil || !pinned.IsPinned() {
+			return true
+		}
+
+		if followTag {
+			if _, commit, err := getFollowedTag(source, followPattern); err != nil {
+				slog.Debug(fmt.Sprintf("Could not find followed tag for %s: %s", source.Original, err))
+				return true
+			} else if commit != pinned.FragmentValue {
+				return true
+			}
+
+			continue
+		}
+
+		refName := "HEAD"
+
+		switch source.FragmentType {
+		case "":
+		case pacman.FragmentTypeBranch:
+			refName = fmt.Sprintf("refs/heads/%s", source.FragmentValue)
+		case pacman.FragmentTypeTag:
+			refName = fmt.Sprintf("refs/tags/%s", source.FragmentValue)
+		default:
+			return true
+		}
+
+		url := source.GetVcsUrl()
+		revision, err := git.GetRemoteRevision(url, refName)
+
+		if err != nil {
+			slog.Debug(fmt.Sprintf("Could not list remote refs for %s: %s", url, err))
+			return true
+		}
+
+		if revision != pinned.FragmentValue {
+			slog.Debug(fmt.Sprintf("Source %s moved from %s to %s", source.Original, pinned.FragmentValue, revision))
+			return true
+		}
+	}
+
+	return false
+}
+
+func (pconfig *PackageConfig) getMovedTag(folder string, tag string, commit string) string {
+	if pconfig.Vcs == nil {
+		return ""
+	}
+
+	for _, previous := range pconfig.Vcs.Tags {
+		if previous.Source == folder && previous.Tag == tag && previous.Commit != commit {
+			return fmt.Sprintf("tag %s of source %s moved from %s to %s", tag, folder, previous.Commit, commit)
+		}
+	}
+
+	return ""
+}
+
+func getFollowedTag(source *pacman.Source, pattern string) (string, string, error) {
+	tagRegex, err := regexp.Compile(pattern)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	tags, err := git.GetRemoteTags(source.GetVcsUrl())
+
+	if err != nil {
+		return "", "", err
+	}
+
+	newestTag := ""
+
+	for tag := range tags {
+		if !tagRegex.MatchString(tag) {
+			continue
+		}
+
+		if newestTag == "" {
+			newestTag = tag
+		} else if result := pacman.ComparePkgver(newestTag, tag); result < 0 || (result == 0 && newestTag < tag) {
+			newestTag = tag
+		}
+	}
+
+	if newestTag == "" {
+		return "", "", errors.New(fmt.Sprintf("no tags matching %s found for %s", pattern, source.GetVcsUrl()))
+	}
+
+	return newestTag, tags[newestTag], nil
+}
@@ -0,0 +1,50 @@
+package pkg
+
+import (
+	"errors"
+	"github.com/ryanpetris/aur-builder/config"
+	"github.com/ryanpetris/aur-builder/git"
+	"github.com/ryanpetris/aur-builder/pacman"
+	"os"
+	"path/filepath"
+)
+
+func prepareVcsCache(pkgbase string, vcsSources map[string]*pacman.Source) error {
+	if config.GetVcsCachePath() == "" {
+		return nil
+	}
+
+	srcdestPath := config.GetVcsSrcdestPath(pkgbase)
+
+	if err := os.MkdirAll(srcdestPath, 0777); err != nil {
+		return err
+	}
+
+	for folder, source := range vcsSources {
+		if source.VcsType != pacman.VcsTypeGit {
+			continue
+		}
+
+		mirrorPath, err := git.UpdateMirror(source.GetVcsUrl())
+
+		if err != nil {
+			return err
+		}
+
+		linkPath := filepath.Join(srcdestPath, folder)
+
+		if target, err := os.Readlink(linkPath); err == nil && target == mirrorPath {
+			continue
+		}
+
+		if err := os.RemoveAll(linkPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		if err := os.Symlink(mirrorPath, linkPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
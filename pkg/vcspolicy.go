@@ -0,0 +1,161 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ryanpetris/aur-builder/config"
+	"github.com/ryanpetris/aur-builder/git"
+	"github.com/ryanpetris/aur-builder/pacman"
+	"log/slog"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (pconfig *PackageConfig) CheckVcsPolicy(pkgbase string, oldVcs *PackageVcs) (bool, string, error) {
+	if oldVcs == nil || pconfig.Vcs == nil || pconfig.Vcs.Policy == nil {
+		return true, "", nil
+	}
+
+	policy := pconfig.Vcs.Policy
+
+	if policy.MinInterval != "" && pconfig.Vcs.LastBump != "" {
+		interval, err := parsePolicyDuration(policy.MinInterval)
+
+		if err != nil {
+			return false, "", err
+		}
+
+		lastBump, err := time.Parse(time.RFC3339, pconfig.Vcs.LastBump)
+
+		if err != nil {
+			return false, "", err
+		}
+
+		if nextBump := lastBump.Add(interval); time.Now().Before(nextBump) {
+			return false, fmt.Sprintf("next update allowed after %s", nextBump.Format(time.RFC3339)), nil
+		}
+	}
+
+	if policy.MinCommits <= 0 && !policy.NewTagOnly && len(policy.Paths) == 0 {
+		return true, "", nil
+	}
+
+	commits, err := getVcsPolicyCommits(pkgbase, oldVcs, pconfig.Vcs, policy.Paths)
+
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Unable to check VCS history for %s, ignoring policy: %s", pkgbase, err))
+		return true, "", nil
+	}
+
+	if len(policy.Paths) > 0 && len(commits) == 0 {
+		return false, fmt.Sprintf("no new commits touch %s", strings.Join(policy.Paths, ", ")), nil
+	}
+
+	if policy.MinCommits > 0 && len(commits) < policy.MinCommits {
+		return false, fmt.Sprintf("%d of %d required new commits", len(commits), policy.MinCommits), nil
+	}
+
+	if policy.NewTagOnly {
+		hasTag := false
+
+		for _, commit := range commits {
+			if len(commit.Tags) > 0 {
+				hasTag = true
+				break
+			}
+		}
+
+		if !hasTag {
+			return false, "no new tags", nil
+		}
+	}
+
+	return true, "", nil
+}
+
+func getVcsPolicyCommits(pkgbase string, oldVcs *PackageVcs, newVcs *PackageVcs, paths []string) ([]*git.CommitInfo, error) {
+	sourcesPath := path.Join(config.GetMergedPath(pkgbase), "src")
+
+	var result []*git.CommitInfo
+
+	for _, newOverride := range newVcs.SourceOverrides {
+		var oldOverride *PackageConfigOverrideFromTo
+
+		for _, override := range oldVcs.SourceOverrides {
+			if override.From == newOverride.From {
+				oldOverride = override
+				break
+			}
+		}
+
+		if oldOverride == nil {
+			return nil, errors.New(fmt.Sprintf("no previous pin for source %s", newOverride.From))
+		}
+
+		if oldOverride.To == newOverride.To {
+			continue
+		}
+
+		oldSource, err := pacman.ParseSource(oldOverride.To)
+
+		if err != nil {
+			return nil, err
+		}
+
+		newSource, err := pacman.ParseSource(newOverride.To)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if newSource.VcsType != pacman.VcsTypeGit || !oldSource.IsPinned() || !newSource.IsPinned() {
+			return nil, errors.New(fmt.Sprintf("cannot compare history for source %s", newOverride.From))
+		}
+
+		commits, err := git.GetCommitsBetween(path.Join(sourcesPath, newSource.GetFolder()), oldSource.FragmentValue, newSource.FragmentValue)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, commit := range commits {
+			if len(paths) == 0 || matchesPolicyPaths(commit.Paths, paths) {
+				result = append(result, commit)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func matchesPolicyPaths(files []string, patterns []string) bool {
+	for _, file := range files {
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(pattern, file); matched {
+				return true
+			}
+
+			if strings.HasPrefix(file, strings.TrimSuffix(pattern, "/")+"/") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func parsePolicyDuration(value string) (time.Duration, error) {
+	if days, found := strings.CutSuffix(value, "d"); found {
+		count, err := strconv.Atoi(days)
+
+		if err != nil {
+			return 0, errors.New(fmt.Sprintf("invalid duration %s", value))
+		}
+
+		return time.Duration(count) * 24 * time.Hour, nil
+	}
+
+	return time.ParseDuration(value)
+}
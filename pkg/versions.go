@@ -1,14 +1,13 @@
 package pkg
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"github.com/ryanpetris/aur-builder/config"
 	"github.com/ryanpetris/aur-builder/misc"
 	"github.com/ryanpetris/aur-builder/pacman"
+	"log/slog"
 	"os"
-	"os/exec"
 	"path"
 	"strconv"
 	"strings"
@@ -103,6 +102,10 @@ func GetMergedVersionParts(pkgbase string) (string, string, int, int, error) {
 }
 
 func GetUpstreamPkgnames(pkgbase string) ([]string, error) {
+	if srcinfo := loadUpstreamSrcinfo(pkgbase); srcinfo != nil {
+		return srcinfo.Pkgname, nil
+	}
+
 	basePath := config.GetUpstreamPath(pkgbase)
 	pkgbuildPath := path.Join(basePath, "PKGBUILD")
 
@@ -120,6 +123,10 @@ func GetUpstreamVersion(pkgbase string) (string, error) {
 }
 
 func GetUpstreamVersionParts(pkgbase string) (string, string, int, int, error) {
+	if srcinfo := loadUpstreamSrcinfo(pkgbase); srcinfo != nil {
+		return getSrcinfoVersionParts(srcinfo)
+	}
+
 	basePath := config.GetUpstreamPath(pkgbase)
 	pkgbuildPath := path.Join(basePath, "PKGBUILD")
 
@@ -127,206 +134,129 @@ func GetUpstreamVersionParts(pkgbase string) (string, string, int, int, error) {
 }
 
 func GetVersionString(epoch string, pkgver string, pkgrel int, subpkgrel int) string {
-	pkgrelstr := fmt.Sprintf("%d", pkgrel)
+	return pacman.NewVersion(epoch, pkgver, pkgrel, subpkgrel).String()
+}
+
+func loadUpstreamSrcinfo(pkgbase string) *pacman.PkgInfo {
+	srcinfoPath := path.Join(config.GetUpstreamPath(pkgbase), ".SRCINFO")
 
-	if subpkgrel > 0 {
-		pkgrelstr = fmt.Sprintf("%s.%d", pkgrelstr, subpkgrel)
+	if _, err := os.Stat(srcinfoPath); err != nil {
+		return nil
 	}
 
-	version := fmt.Sprintf("%s-%s", pkgver, pkgrelstr)
+	srcinfo, err := pacman.LoadSrcinfo(srcinfoPath)
 
-	if epoch != "" {
-		version = fmt.Sprintf("%s:%s", epoch, version)
+	if err != nil {
+		slog.Warn(fmt.Sprintf("Ignoring invalid .SRCINFO for pkgbase %s: %s", pkgbase, err))
+		return nil
 	}
 
-	return version
+	return srcinfo
 }
 
-func getPkgbuildPkgnames(pkgbuildPath string) ([]string, error) {
-	cmdText := `
-set -e
+func getSrcinfoVersionParts(srcinfo *pacman.PkgInfo) (string, string, int, int, error) {
+	pkgrel, subpkgrel, err := getPkgrelParts(srcinfo.Pkgrel)
 
-source "${1}"
+	if err != nil {
+		return "", "", 0, 0, err
+	}
 
-echo "${pkgname[@]}"
-`
+	epoch := ""
 
-	var stdoutBuf bytes.Buffer
+	if srcinfo.Epoch > 0 {
+		epoch = strconv.Itoa(srcinfo.Epoch)
+	}
 
-	cmd := exec.Command("bash", "-c", cmdText, "bash", pkgbuildPath)
-	cmd.Stdout = &stdoutBuf
+	return epoch, srcinfo.Pkgver, pkgrel, subpkgrel, nil
+}
 
-	err := cmd.Run()
+func getPkgbuildPkgnames(pkgbuildPath string) ([]string, error) {
+	pkgbuild, err := pacman.LoadPkgbuild(pkgbuildPath)
 
 	if err != nil {
 		return nil, err
 	}
 
-	line := strings.Split(stdoutBuf.String(), "\n")[0]
-
-	return strings.Split(line, " "), nil
+	return pkgbuild.GetArray("pkgname"), nil
 }
 
 func getPkgbuildSources(pkgbuildPath string) (map[string][]string, error) {
-	cmdText := `
-set -e
-
-source "${1}"
-
-mapfile -t SOURCE_ARRAYS < <(compgen -v source)
-
-for SOURCE_ARRAY in "${SOURCE_ARRAYS[@]}"; do
-	mapfile -t SOURCE_ITEMS < <(IFS=$'\n'; eval echo '"'"\${${SOURCE_ARRAY}[*]}"'"')
-
-	for ITEM in "${SOURCE_ITEMS[@]}"; do
-		echo "$SOURCE_ARRAY" "$ITEM"
-	done
-done
-`
-	var stdoutBuf bytes.Buffer
-
-	cmd := exec.Command("bash", "-c", cmdText, "bash", pkgbuildPath)
-	cmd.Stdout = &stdoutBuf
-	cmd.Stderr = os.Stderr
-
-	err := cmd.Run()
+	pkgbuild, err := pacman.LoadPkgbuild(pkgbuildPath)
 
 	if err != nil {
 		return nil, err
 	}
 
-	lines := strings.Split(stdoutBuf.String(), "\n")
 	result := map[string][]string{}
 
-	for _, line := range lines {
-		if len(line) == 0 {
+	for name, variable := range pkgbuild.Variables {
+		if !strings.HasPrefix(name, "source") || len(variable.Values) == 0 {
 			continue
 		}
 
-		lineParts := strings.SplitN(line, " ", 2)
-
-		if len(lineParts) < 1 {
-			return nil, errors.New(fmt.Sprintf("Invalid output: %s", line))
-		}
-
-		sourceType := lineParts[0]
-		source := lineParts[1]
-
-		if value, ok := result[sourceType]; ok {
-			result[sourceType] = append(value, source)
-		} else {
-			result[sourceType] = []string{source}
-		}
+		result[name] = variable.Values
 	}
 
 	return result, nil
 }
 
 func getPkgbuildVcsPkgver(pkgbuildPath string) (string, int, int, error) {
-	cmdText := `
-set -e
+	pkgbuild, err := pacman.LoadPkgbuild(pkgbuildPath)
 
-source "${1}"
-
-_r_pkgver="$([[ "$(type -t pkgver || true)" == "function" ]] && srcdir='src' pkgver || echo "$pkgver")"
-_r_pkgrel="1"
+	if err != nil {
+		return "", 0, 0, err
+	}
 
-if [[ "$pkgver" == "$_r_pkgver" ]]; then
-	_r_pkgrel="$pkgrel"
-fi
+	pkgver := pkgbuild.GetVar("pkgver")
+	pkgrel := "1"
 
-echo "${_r_pkgver}"
-echo "${_r_pkgrel}"
-`
+	if pkgbuild.HasFunction("pkgver") {
+		out, err := pacman.RunPkgbuildFunction(pkgbuildPath, "pkgver", pacman.PkgbuildOptions{AllowExec: true})
 
-	var stdoutBuf bytes.Buffer
+		if err != nil {
+			return "", 0, 0, err
+		}
 
-	cmd := exec.Command("bash", "-c", cmdText, "bash", path.Base(pkgbuildPath))
-	cmd.Dir = path.Dir(pkgbuildPath)
-	cmd.Stdout = &stdoutBuf
+		parts := misc.FilterEmptyString(strings.Split(out, "\n"))
 
-	err := cmd.Run()
+		if len(parts) != 1 {
+			return "", 0, 0, errors.New(fmt.Sprintf("invalid pkgver result: %s", out))
+		}
 
-	if err != nil {
-		return "", 0, 0, err
+		pkgver = parts[0]
 	}
 
-	parts := misc.FilterEmptyString(strings.Split(stdoutBuf.String(), "\n"))
-
-	if len(parts) != 2 {
-		return "", 0, 0, errors.New(fmt.Sprintf("invalid pkgver result: %s", stdoutBuf.String()))
+	if pkgver == pkgbuild.GetVar("pkgver") {
+		pkgrel = pkgbuild.GetVar("pkgrel")
 	}
 
-	pkgrel, subpkgrel, err := getPkgrelParts(parts[1])
+	intpkgrel, subpkgrel, err := getPkgrelParts(pkgrel)
 
 	if err != nil {
 		return "", 0, 0, err
 	}
 
-	return parts[0], pkgrel, subpkgrel, nil
+	return pkgver, intpkgrel, subpkgrel, nil
 }
 
 func getPkgbuildVersionParts(pkgbuildPath string) (string, string, int, int, error) {
-	cmdText := `
-set -e
-
-source "${1}"
-
-echo "${epoch}"
-echo "${pkgver}"
-echo "${pkgrel}"
-`
-
-	var stdoutBuf bytes.Buffer
-
-	cmd := exec.Command("bash", "-c", cmdText, "bash", pkgbuildPath)
-	cmd.Stdout = &stdoutBuf
-
-	err := cmd.Run()
+	pkgbuild, err := pacman.LoadPkgbuild(pkgbuildPath)
 
 	if err != nil {
 		return "", "", 0, 0, err
 	}
 
-	parts := strings.Split(stdoutBuf.String(), "\n")
-	pkgrel, subpkgrel, err := getPkgrelParts(parts[2])
+	pkgrel, subpkgrel, err := getPkgrelParts(pkgbuild.GetVar("pkgrel"))
 
 	if err != nil {
 		return "", "", 0, 0, err
 	}
 
-	return parts[0], parts[1], pkgrel, subpkgrel, nil
+	return pkgbuild.GetVar("epoch"), pkgbuild.GetVar("pkgver"), pkgrel, subpkgrel, nil
 }
 
 func getPkgrelParts(pkgrel string) (int, int, error) {
-	intpkgrel := 0
-	intsubpkgrel := 0
-
-	if strings.Contains(pkgrel, ".") {
-		pkgrelParts := strings.Split(pkgrel, ".")
-
-		if len(pkgrelParts) != 2 {
-			return 0, 0, errors.New("invalid pkgrel")
-		}
-
-		if val, err := strconv.Atoi(pkgrelParts[0]); err != nil {
-			return 0, 0, err
-		} else {
-			intpkgrel = val
-		}
-
-		if val, err := strconv.Atoi(pkgrelParts[1]); err != nil {
-			return 0, 0, err
-		} else {
-			intsubpkgrel = val
-		}
-	} else {
-		if val, err := strconv.Atoi(pkgrel); err != nil {
-			return 0, 0, err
-		} else {
-			intpkgrel = val
-		}
-	}
+	version := &pacman.Version{Pkgrel: pkgrel}
 
-	return intpkgrel, intsubpkgrel, nil
+	return version.GetPkgrelParts()
 }